@@ -9,15 +9,14 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd"
 )
 
-// Run runs the command, if args are not nil they will be set on the command
+// Run runs the command, if args are not nil they will be used instead of os.Args
 func Run(args []string) error {
 	configureTerminalForAnsiEscapes()
-	cmd := cmd.Main(args)
-	if len(args) > 0 {
-		args = args[1:]
-		cmd.SetArgs(args)
+	if args == nil {
+		args = os.Args
 	}
-	return cmd.Execute()
+	o := &cmd.RootOptions{}
+	return o.Execute(args[1:])
 }
 
 const (
@@ -3,15 +3,16 @@
 package app
 
 import (
+	"os"
+
 	"github.com/jenkins-x/jx/pkg/cmd"
 )
 
-// Run runs the command, if args are not nil they will be set on the command
+// Run runs the command, if args are not nil they will be used instead of os.Args
 func Run(args []string) error {
-	c := cmd.Main(args)
-	if args != nil {
-		args = args[1:]
-		c.SetArgs(args)
+	if args == nil {
+		args = os.Args
 	}
-	return c.Execute()
+	o := &cmd.RootOptions{}
+	return o.Execute(args[1:])
 }
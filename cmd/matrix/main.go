@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/spf13/pflag"
+)
+
+// generates the dependency matrix from the plugin catalog
+func main() {
+	dir := "dependency-matrix"
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+	flags.StringVar(&dir, "dir", dir, "The directory to write the dependency matrix files to")
+	err := flags.Parse(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = plugins.DefaultCatalog.DependencyMatrix().WriteFiles(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
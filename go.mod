@@ -3,6 +3,7 @@ module github.com/jenkins-x/jx
 require (
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/cpuguy83/go-md2man v1.0.10
+	github.com/inconshreveable/go-update v0.0.0-20160112193335-8152e7eb6ccf
 	github.com/jenkins-x/jx-api/v4 v4.0.33
 	github.com/jenkins-x/jx-helpers/v3 v3.0.119
 	github.com/jenkins-x/jx-kube-client/v3 v3.0.2
@@ -17,7 +18,7 @@ require (
 	k8s.io/apimachinery v0.20.8
 	k8s.io/client-go v11.0.0+incompatible
 	sigs.k8s.io/kustomize/kyaml v0.10.5
-
+	sigs.k8s.io/yaml v1.2.0
 )
 
 replace (
@@ -30,4 +31,4 @@ replace (
 	k8s.io/client-go => k8s.io/client-go v0.20.6
 )
 
-go 1.15
+go 1.16
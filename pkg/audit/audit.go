@@ -0,0 +1,255 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-kube-client/v3/pkg/kubeclient"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvAuditLog the environment variable for the directory to write the audit log to which enables the audit log
+	EnvAuditLog = "JX_AUDIT_LOG"
+
+	// DirName the name of the audit log directory inside the jx home dir
+	DirName = "audit"
+
+	// FileExtension the extension of the JSON lines files in the audit log directory
+	FileExtension = ".jsonl"
+
+	fileDateFormat = "2006-01-02"
+)
+
+// Entry an audit log entry for a plugin invocation
+type Entry struct {
+	// Timestamp when the plugin was started
+	Timestamp time.Time `json:"timestamp"`
+	// User the local user who ran the plugin
+	User string `json:"user,omitempty"`
+	// Context the current kube context
+	Context string `json:"context,omitempty"`
+	// Namespace the current kube namespace
+	Namespace string `json:"namespace,omitempty"`
+	// Plugin the plugin binary name such as `jx-gitops`
+	Plugin string `json:"plugin"`
+	// Version the version of the plugin if known
+	Version string `json:"version,omitempty"`
+	// Path the path of the plugin executable
+	Path string `json:"path,omitempty"`
+	// Args the command line arguments with any secret values redacted
+	Args []string `json:"args,omitempty"`
+	// DurationMillis how long the plugin ran for in milliseconds
+	DurationMillis int64 `json:"durationMillis"`
+	// ExitCode the exit code of the plugin
+	ExitCode int `json:"exitCode"`
+	// Error why the plugin could not be run such as a pre hook denying it
+	Error string `json:"error,omitempty"`
+}
+
+// Duration returns the duration of the invocation
+func (e *Entry) Duration() time.Duration {
+	return time.Duration(e.DurationMillis) * time.Millisecond
+}
+
+// Query filters audit log entries. Empty values match all entries
+type Query struct {
+	// Since only entries at or after this time
+	Since time.Time
+	// Until only entries before this time
+	Until time.Time
+	// Plugin only entries for this plugin with or without the `jx-` prefix
+	Plugin string
+	// Context only entries for kube contexts matching this glob
+	Context string
+}
+
+// Matches returns true if the entry matches the query
+func (q *Query) Matches(e *Entry) bool {
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.Plugin != "" && strings.TrimPrefix(q.Plugin, "jx-") != strings.TrimPrefix(e.Plugin, "jx-") {
+		return false
+	}
+	if q.Context != "" {
+		matched, err := path.Match(q.Context, e.Context)
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Log an audit log of JSON lines files, one per day, in a directory
+type Log struct {
+	Dir string
+}
+
+// DefaultDir returns the default audit log directory inside the jx home dir
+func DefaultDir() (string, error) {
+	dir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DirName), nil
+}
+
+// LoadLog returns the audit log if it is enabled via the configuration or $JX_AUDIT_LOG otherwise nil
+func LoadLog(cfg *config.Config) (*Log, error) {
+	if dir := os.Getenv(EnvAuditLog); dir != "" {
+		return &Log{Dir: dir}, nil
+	}
+	if !cfg.Audit.Enabled {
+		return nil, nil
+	}
+	return FindLog(cfg)
+}
+
+// FindLog returns the audit log from the configuration or $JX_AUDIT_LOG whether it is enabled or not
+func FindLog(cfg *config.Config) (*Log, error) {
+	dir := os.Getenv(EnvAuditLog)
+	if dir == "" {
+		dir = cfg.Audit.Dir
+	}
+	if dir == "" {
+		var err error
+		dir, err = DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Log{Dir: dir}, nil
+}
+
+// Write appends the entry to the file for the day of its timestamp
+func (l *Log) Write(e *Entry) error {
+	err := os.MkdirAll(l.Dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create audit log dir %s", l.Dir)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audit log entry")
+	}
+	fileName := filepath.Join(l.Dir, e.Timestamp.UTC().Format(fileDateFormat)+FileExtension)
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open audit log %s", fileName)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "failed to write audit log %s", fileName)
+	}
+	return nil
+}
+
+// Query returns the entries matching the query in time order
+func (l *Log) Query(q Query) ([]Entry, error) {
+	fileInfos, err := ioutil.ReadDir(l.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read audit log dir %s", l.Dir)
+	}
+	var answer []Entry
+	for _, f := range fileInfos {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, FileExtension) {
+			continue
+		}
+		// lets skip files for days outside of the time range
+		day, err := time.Parse(fileDateFormat, strings.TrimSuffix(name, FileExtension))
+		if err == nil {
+			if !q.Since.IsZero() && day.Add(24*time.Hour).Before(q.Since) {
+				continue
+			}
+			if !q.Until.IsZero() && day.After(q.Until) {
+				continue
+			}
+		}
+		entries, err := loadEntries(filepath.Join(l.Dir, name), q)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, entries...)
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].Timestamp.Before(answer[j].Timestamp)
+	})
+	return answer, nil
+}
+
+func loadEntries(fileName string, q Query) ([]Entry, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open audit log %s", fileName)
+	}
+	defer f.Close()
+
+	var answer []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		e := Entry{}
+		err = json.Unmarshal([]byte(text), &e)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse line %d of audit log %s", line, fileName)
+		}
+		if q.Matches(&e) {
+			answer = append(answer, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read audit log %s", fileName)
+	}
+	return answer, nil
+}
+
+// CurrentUser returns the name of the local user
+func CurrentUser() string {
+	u, err := user.Current()
+	if err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// KubeContext returns the current kube context and namespace or empty strings if there is no kube config
+func KubeContext() (string, string) {
+	cfg, _, err := kubeclient.LoadConfig()
+	if err != nil || cfg == nil {
+		return "", ""
+	}
+	ns := ""
+	ctx := kubeclient.CurrentContext(cfg)
+	if ctx != nil {
+		ns = ctx.Namespace
+	}
+	if ns == "" && cfg.CurrentContext != "" {
+		ns = "default"
+	}
+	return cfg.CurrentContext, ns
+}
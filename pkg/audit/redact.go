@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted the value used in place of secret values
+const Redacted = "*****"
+
+var (
+	secretNames = []string{"password", "passwd", "secret", "token", "apikey", "api-key", "api_key", "credential", "private-key"}
+
+	secretValuePatterns = []*regexp.Regexp{
+		// GitHub tokens
+		regexp.MustCompile(`^(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{20,}$`),
+		regexp.MustCompile(`^github_pat_[A-Za-z0-9_]{20,}$`),
+		// GitLab personal access tokens
+		regexp.MustCompile(`^glpat-[A-Za-z0-9_\-]{20,}$`),
+		// AWS access keys
+		regexp.MustCompile(`^(AKIA|ASIA)[A-Z0-9]{16}$`),
+		// JWTs
+		regexp.MustCompile(`^eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+$`),
+	}
+)
+
+// Redact returns a copy of the command line arguments with the values of secret looking flags,
+// `NAME=value` arguments, tokens and URL passwords redacted
+func Redact(args []string) []string {
+	answer := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext && !strings.HasPrefix(arg, "-") {
+			answer[i] = Redacted
+			redactNext = false
+			continue
+		}
+		redactNext = false
+
+		eq := strings.Index(arg, "=")
+		switch {
+		case strings.HasPrefix(arg, "-") && eq < 0:
+			// the value may be the next argument
+			answer[i] = arg
+			redactNext = isSecretName(arg)
+		case eq > 0 && isSecretName(arg[:eq]):
+			answer[i] = arg[:eq+1] + Redacted
+		case eq > 0:
+			answer[i] = arg[:eq+1] + redactValue(arg[eq+1:])
+		default:
+			answer[i] = redactValue(arg)
+		}
+	}
+	return answer
+}
+
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactValue(value string) string {
+	for _, r := range secretValuePatterns {
+		if r.MatchString(value) {
+			return Redacted
+		}
+	}
+	if strings.Contains(value, "://") && strings.Contains(value, "@") {
+		u, err := url.Parse(value)
+		if err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				// lets avoid escaping the redacted password
+				u.User = url.User(u.User.Username())
+				return strings.Replace(u.String(), "@", ":"+Redacted+"@", 1)
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Commands for working with the local audit log of plugin invocations.
+
+		The audit log is enabled by setting 'audit.enabled: true' in the jx config file or by setting $JX_AUDIT_LOG to the directory to write the audit log to
+`)
+
+	cmdExample = templates.Examples(`
+		# view the plugins run in the last day
+		jx audit log --since 24h
+	`)
+)
+
+// Options the options for the audit command
+type Options struct {
+	Cmd *cobra.Command
+}
+
+// NewCmdAudit creates a command object for the command
+func NewCmdAudit() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	o.Cmd = &cobra.Command{
+		Use:     "audit",
+		Short:   "Commands for working with the audit log of plugin invocations",
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	o.Cmd.AddCommand(cobras.SplitCommand(NewCmdAuditLog()))
+	return o.Cmd, o
+}
+
+// Run implements this command
+func (o *Options) Run() error {
+	return o.Cmd.Help()
+}
@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/audit"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLogLong = templates.LongDesc(`
+		Displays the entries in the audit log of plugin invocations
+`)
+
+	cmdLogExample = templates.Examples(`
+		# view all the entries in the audit log
+		jx audit log
+
+		# view the gitops commands run against production clusters in the last week
+		jx audit log --plugin gitops --context 'prod-*' --since 168h
+
+		# view the entries for a day
+		jx audit log --since 2021-03-01 --until 2021-03-02
+	`)
+)
+
+// LogOptions the options for viewing the audit log
+type LogOptions struct {
+	Dir     string
+	Since   string
+	Until   string
+	Plugin  string
+	Context string
+	Entries []audit.Entry
+	Out     io.Writer
+	Output  *output.Format
+}
+
+// NewCmdAuditLog creates a command object for the command
+func NewCmdAuditLog() (*cobra.Command, *LogOptions) {
+	o := &LogOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "log",
+		Short:   "Displays the entries in the audit log",
+		Aliases: []string{"logs"},
+		Long:    cmdLogLong,
+		Example: cmdLogExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			o.Output, err = output.FormatFromCommand(cmd)
+			helper.CheckErr(err)
+			o.Out = cmd.OutOrStdout()
+			err = o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", "", "the audit log directory. Defaults to the configured audit log directory")
+	cmd.Flags().StringVarP(&o.Since, "since", "", "", "only show entries since this time. Either a duration such as '24h' or a time such as '2021-03-01' or '2021-03-01T12:00:00Z'")
+	cmd.Flags().StringVarP(&o.Until, "until", "", "", "only show entries before this time. Either a duration such as '1h' or a time such as '2021-03-01' or '2021-03-01T12:00:00Z'")
+	cmd.Flags().StringVarP(&o.Plugin, "plugin", "p", "", "only show entries for the plugin such as 'gitops'")
+	cmd.Flags().StringVarP(&o.Context, "context", "c", "", "only show entries for kube contexts matching this glob such as 'prod-*'")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *LogOptions) Run() error {
+	l := &audit.Log{Dir: o.Dir}
+	if l.Dir == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		l, err = audit.FindLog(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	q := audit.Query{
+		Plugin:  o.Plugin,
+		Context: o.Context,
+	}
+	var err error
+	q.Since, err = ParseTime(o.Since, now)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --since")
+	}
+	q.Until, err = ParseTime(o.Until, now)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --until")
+	}
+
+	o.Entries, err = l.Query(q)
+	if err != nil {
+		return err
+	}
+	if len(o.Entries) == 0 {
+		log.Logger().Infof("no audit log entries found in %s", l.Dir)
+		return nil
+	}
+
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.Output != nil {
+		var items []interface{}
+		for i := range o.Entries {
+			items = append(items, &o.Entries[i])
+		}
+		r, err := output.NewResult("AuditEntry", []string{"timestamp", "user", "context", "namespace", "plugin", "version", "exitCode", "args", "error"}, items...)
+		if err != nil {
+			return err
+		}
+		return output.Render(o.Out, o.Output, []output.Result{*r})
+	}
+
+	t := table.CreateTable(o.Out)
+	t.AddRow("TIME", "USER", "CONTEXT", "NAMESPACE", "PLUGIN", "VERSION", "EXIT", "DURATION", "ARGS", "ERROR")
+	for i := range o.Entries {
+		e := &o.Entries[i]
+		t.AddRow(e.Timestamp.Local().Format(time.RFC3339), e.User, e.Context, e.Namespace, e.Plugin, e.Version,
+			strconv.Itoa(e.ExitCode), e.Duration().String(), strings.Join(e.Args, " "), e.Error)
+	}
+	t.Render()
+	return nil
+}
+
+// ParseTime parses either a duration before now such as `24h` or a date or RFC 3339 time
+func ParseTime(text string, now time.Time) (time.Time, error) {
+	if text == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.ParseDuration(text)
+	if err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, text)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.ParseInLocation("2006-01-02", text, time.Local)
+	if err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.Errorf("could not parse %s as a duration such as 24h or a time such as 2021-03-01 or 2021-03-01T12:00:00Z", text)
+}
@@ -2,13 +2,16 @@ package dashboard
 
 import (
 	"context"
+	"io"
 	"net/url"
+	"os"
 
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/services"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/output"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +32,8 @@ type Options struct {
 	BasicAuthSecretName string
 	NoBrowser           bool
 	Quiet               bool
+	Output              *output.Format
+	Out                 io.Writer
 }
 
 var (
@@ -41,6 +46,9 @@ var (
 
 		# display the URL only without opening a browser
 		jx --no-open
+
+		# display the dashboard URL as JSON
+		jx dashboard --output json
 `)
 
 	info = termcolor.ColorInfo
@@ -56,7 +64,11 @@ func NewCmdDashboard() (*cobra.Command, *Options) {
 		Long:    cmdLong,
 		Example: cmdExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := o.Run()
+			var err error
+			o.Output, err = output.FormatFromCommand(cmd)
+			helper.CheckErr(err)
+			o.Out = cmd.OutOrStdout()
+			err = o.Run()
 			helper.CheckErr(err)
 		},
 	}
@@ -84,6 +96,22 @@ func (o *Options) Run() error {
 		return errors.Errorf("no dashboard URL. Check you have 'chart: jx3/jx-pipelines-visualizer' in your helmfile.yaml")
 	}
 
+	// structured output implies we don't open a browser
+	if o.Output != nil {
+		r, err := output.NewResult("Dashboard", []string{"name", "namespace", "url"}, map[string]string{
+			"name":      o.ServiceName,
+			"namespace": o.Namespace,
+			"url":       u,
+		})
+		if err != nil {
+			return err
+		}
+		if o.Out == nil {
+			o.Out = os.Stdout
+		}
+		return output.Render(o.Out, o.Output, []output.Result{*r})
+	}
+
 	log.Logger().Infof("Jenkins X dashboard is running at: %s", info(u))
 
 	if o.NoBrowser {
@@ -3,6 +3,7 @@ package namespace
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
@@ -14,6 +15,7 @@ import (
 	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
 	"github.com/jenkins-x/jx-kube-client/v3/pkg/kubeclient"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/output"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/rest"
 
@@ -43,6 +45,8 @@ type Options struct {
 	PickEnv    bool
 	Create     bool
 	QuiteMode  bool
+	Output     *output.Format
+	Out        io.Writer
 	BatchMode  bool
 }
 
@@ -53,6 +57,9 @@ var (
 		# view the current namespace
 		jx --batch-mode ns
 
+		# view the current namespace as JSON
+		jx ns --output json
+
 		# interactively select the namespace to switch to
 		jx ns
 
@@ -85,7 +92,11 @@ func NewCmdNamespace() (*cobra.Command, *Options) {
 		Example: cmdExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			o.Args = args
-			err := o.Run()
+			var err error
+			o.Output, err = output.FormatFromCommand(cmd)
+			helper.CheckErr(err)
+			o.Out = cmd.OutOrStdout()
+			err = o.Run()
 			helper.CheckErr(err)
 		},
 	}
@@ -131,7 +142,8 @@ func (o *Options) Run() error {
 		ns = namespace(o)
 	}
 
-	if ns == "" && !o.BatchMode {
+	// structured output is for scripts so render the current namespace rather than prompting
+	if ns == "" && !o.BatchMode && o.Output == nil {
 		ns, err = pickNamespace(o, client, currentNS)
 		if err != nil {
 			return err
@@ -143,6 +155,14 @@ func (o *Options) Run() error {
 		if err != nil {
 			return err
 		}
+		if o.Output != nil {
+			server := ""
+			if ctx != nil {
+				ns = ctx.Namespace
+				server = kube.Server(cfg, ctx)
+			}
+			return o.renderNamespace(ns, cfg.CurrentContext, server)
+		}
 		if ctx == nil {
 			log.Logger().Infof("No kube context - probably in a unit test or pod?\n")
 		} else {
@@ -153,6 +173,9 @@ func (o *Options) Run() error {
 			ns = currentNS
 		}
 		server := kube.CurrentServer(cfg)
+		if o.Output != nil {
+			return o.renderNamespace(ns, cfg.CurrentContext, server)
+		}
 		if config == nil {
 			log.Logger().Infof("Using namespace '%s' on server '%s'. No context - probably a unit test or pod?\n", info(ns), info(server))
 
@@ -163,6 +186,22 @@ func (o *Options) Run() error {
 	return nil
 }
 
+// renderNamespace renders the current namespace in the structured output format
+func (o *Options) renderNamespace(ns, context, server string) error {
+	r, err := output.NewResult("Namespace", []string{"namespace", "context", "server"}, map[string]string{
+		"namespace": ns,
+		"context":   context,
+		"server":    server,
+	})
+	if err != nil {
+		return err
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	return output.Render(o.Out, o.Output, []output.Result{*r})
+}
+
 func (o *Options) findNamespaceFromEnv(ns, name string) (string, error) {
 	var err error
 	o.JXClient, ns, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, ns)
@@ -283,10 +322,8 @@ func handleStatusError(err error, client kubernetes.Interface, ns string, create
 			return nil
 		}
 		return err
-	} else {
-		return err
 	}
-	return nil
+	return err
 }
 
 func createNamespace(client kubernetes.Interface, ns string) error {
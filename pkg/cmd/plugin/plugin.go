@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Commands for working with the binary plugins of the Jenkins X CLI
+`)
+
+	cmdExample = templates.Examples(`
+		# list the plugins
+		jx plugin list
+
+		# check the installed plugins are valid
+		jx plugin doctor
+
+		# use a local build of a plugin
+		jx plugin link gitops ./build/jx-gitops
+
+		# view the trusted community plugins
+		jx plugin trust list
+
+		# check if a plugin is allowed by the plugin policies
+		jx plugin policy check gitops
+	`)
+)
+
+// Options the options for the plugin command
+type Options struct {
+	Cmd *cobra.Command
+}
+
+// NewCmdPlugin creates a command object for the command
+func NewCmdPlugin() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	o.Cmd = &cobra.Command{
+		Use:     "plugin",
+		Short:   "Commands for working with plugins",
+		Aliases: []string{"plugins"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	o.Cmd.AddCommand(cobras.SplitCommand(NewCmdPluginDoctor()))
+	o.Cmd.AddCommand(cobras.SplitCommand(NewCmdPluginLink()))
+	o.Cmd.AddCommand(cobras.SplitCommand(NewCmdPluginList()))
+	o.Cmd.AddCommand(NewCmdPluginPolicy())
+	o.Cmd.AddCommand(NewCmdPluginTrust())
+	o.Cmd.AddCommand(cobras.SplitCommand(NewCmdPluginUnlink()))
+
+	return o.Cmd, o
+}
+
+// Run implements this command
+func (o *Options) Run() error {
+	return o.Cmd.Help()
+}
+
+// NewCmdPluginTrust creates the command group for managing trusted community plugins
+func NewCmdPluginTrust() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trust",
+		Short: "Manages the community plugins you have trusted",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := cmd.Help()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(cobras.SplitCommand(NewCmdPluginTrustList()))
+	cmd.AddCommand(cobras.SplitCommand(NewCmdPluginTrustRevoke()))
+	return cmd
+}
@@ -0,0 +1,351 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/survey"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/common"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdDoctorLong = templates.LongDesc(`
+		Validates the installed plugin binaries of the Jenkins X CLI
+
+		For each installed plugin from the catalog (including the plugins pinned in the jx configuration), each installed community plugin, each specific plugin version installed via NAME@VERSION and each linked plugin this checks the file is executable, that it is built for the current operating system and architecture, that it matches the checksum recorded when it was installed and that its version command reports the expected version.
+
+		Plugins installed before checksums were recorded are reported as having no recorded checksum. Reinstall them to record one.
+
+		Broken plugins can be reinstalled. In batch mode the command fails if there are any problems.
+`)
+
+	cmdDoctorExample = templates.Examples(`
+		# check all the installed plugins
+		jx plugin doctor
+
+		# check the gitops plugin
+		jx plugin doctor gitops
+
+		# check the plugins in a CI pipeline failing if there are any problems
+		jx plugin doctor --batch-mode
+	`)
+)
+
+// DoctorOptions the options for validating installed plugins
+type DoctorOptions struct {
+	Config       *config.Config
+	PluginBinDir string
+	Doctor       plugins.Doctor
+	BatchMode    bool
+	Input        input.Interface
+	Out          io.Writer
+	Args         []string
+}
+
+// diagnosis the result of checking a plugin binary
+type diagnosis struct {
+	name     string
+	version  string
+	path     string
+	binDir   string
+	plugin   *jenkinsv1.Plugin
+	problems []string
+	warnings []string
+}
+
+// NewCmdPluginDoctor creates a command object for the command
+func NewCmdPluginDoctor() (*cobra.Command, *DoctorOptions) {
+	o := &DoctorOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "doctor [NAME...]",
+		Short:   "Validates the installed plugin binaries",
+		Long:    cmdDoctorLong,
+		Example: cmdDoctorExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVarP(&o.BatchMode, "batch-mode", "b", false, "Runs in batch mode without prompting to reinstall broken plugins")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *DoctorOptions) Run() error {
+	var err error
+	if !o.BatchMode {
+		o.BatchMode = common.IsBatchMode(nil, os.Environ())
+	}
+	if o.Config == nil {
+		o.Config, err = config.Load()
+		if err != nil {
+			return err
+		}
+	}
+	if o.PluginBinDir == "" {
+		o.PluginBinDir, err = homedir.DefaultPluginBinDir()
+		if err != nil {
+			return errors.Wrap(err, "failed to find plugin bin directory")
+		}
+	}
+
+	results, err := o.diagnose()
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		log.Logger().Infof("no installed or linked plugins found")
+		return nil
+	}
+
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	t := table.CreateTable(o.Out)
+	t.AddRow("NAME", "VERSION", "STATUS")
+	for _, d := range results {
+		status := termcolor.ColorInfo("OK")
+		if len(d.problems) > 0 {
+			status = termcolor.ColorError(strings.Join(d.problems, "; "))
+		} else if len(d.warnings) > 0 {
+			status = termcolor.ColorWarning(strings.Join(d.warnings, "; "))
+		}
+		t.AddRow(d.name, d.version, status)
+	}
+	t.Render()
+
+	unverified := 0
+	for _, d := range results {
+		if len(d.problems) == 0 && len(d.warnings) > 0 {
+			unverified++
+		}
+	}
+	if unverified > 0 {
+		log.Logger().Warnf("%d plugin(s) were installed before checksums were recorded so could not be fully verified; reinstall them to record one", unverified)
+	}
+
+	broken := 0
+	for _, d := range results {
+		if len(d.problems) == 0 {
+			continue
+		}
+		fixed, err := o.reinstall(d)
+		if err != nil {
+			return err
+		}
+		if !fixed {
+			broken++
+		}
+	}
+	if broken > 0 {
+		return errors.Errorf("%d plugin(s) have problems", broken)
+	}
+	return nil
+}
+
+// diagnose checks the installed catalog, community and version specific plugins and the linked plugins
+func (o *DoctorOptions) diagnose() ([]*diagnosis, error) {
+	catalog, err := plugins.CatalogPlugins(o.Config)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, arg := range o.Args {
+		names[plugins.BinaryName(arg)] = true
+	}
+	matches := func(name string) bool {
+		return len(names) == 0 || names[name]
+	}
+	catalogPlugins := map[string]*jenkinsv1.Plugin{}
+	for i := range catalog {
+		catalogPlugins[catalog[i].Spec.Name] = &catalog[i]
+	}
+
+	var answer []*diagnosis
+	for i := range catalog {
+		p := &catalog[i]
+		name := p.Spec.Name
+		if !matches(name) || o.Config.PluginLinks[name] != "" {
+			continue
+		}
+		d, err := o.diagnoseInstalled(p, o.PluginBinDir)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			answer = append(answer, d)
+		}
+	}
+
+	// community plugins which were installed on demand
+	installed, err := plugins.InstalledPlugins(o.PluginBinDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(installed) {
+		if !matches(name) || catalogPlugins[name] != nil || o.Config.PluginLinks[name] != "" {
+			continue
+		}
+		p := plugins.CreateCommunityPlugin(name, installed[name])
+		d, err := o.diagnoseInstalled(&p, o.PluginBinDir)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			answer = append(answer, d)
+		}
+	}
+
+	// specific versions installed side by side via NAME@VERSION
+	versionsDir := filepath.Join(o.PluginBinDir, plugins.VersionsDir)
+	fileInfos, err := ioutil.ReadDir(versionsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to read dir %s", versionsDir)
+	}
+	for _, f := range fileInfos {
+		if !f.IsDir() {
+			continue
+		}
+		version := f.Name()
+		dir := plugins.PluginVersionDir(o.PluginBinDir, version)
+		installed, err := plugins.InstalledPlugins(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sortedKeys(installed) {
+			if !matches(name) || installed[name] != version {
+				continue
+			}
+			p := plugins.CreateCommunityPlugin(name, version)
+			if cp := catalogPlugins[name]; cp != nil {
+				p, err = plugins.PluginVersion(*cp, version)
+				if err != nil {
+					return nil, err
+				}
+			}
+			d, err := o.diagnoseInstalled(&p, dir)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				answer = append(answer, d)
+			}
+		}
+	}
+
+	var linked []string
+	for name := range o.Config.PluginLinks {
+		if matches(name) {
+			linked = append(linked, name)
+		}
+	}
+	sort.Strings(linked)
+	for _, name := range linked {
+		path := o.Config.PluginLinks[name]
+		answer = append(answer, &diagnosis{
+			name:     name,
+			version:  "linked",
+			path:     path,
+			problems: o.Doctor.Diagnose(path, ""),
+		})
+	}
+	return answer, nil
+}
+
+// diagnoseInstalled checks the plugin installed in the bin dir returning nil if it is not installed
+func (o *DoctorOptions) diagnoseInstalled(p *jenkinsv1.Plugin, binDir string) (*diagnosis, error) {
+	path := filepath.Join(binDir, fmt.Sprintf("%s-%s", p.Spec.Name, p.Spec.Version))
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		// plugins are installed on demand
+		return nil, nil
+	}
+	d := &diagnosis{
+		name:     p.Spec.Name,
+		version:  p.Spec.Version,
+		path:     path,
+		binDir:   binDir,
+		plugin:   p,
+		problems: o.Doctor.Diagnose(path, p.Spec.Version),
+	}
+	sum, err := plugins.RecordedChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	if sum == "" {
+		d.warnings = append(d.warnings, "no recorded checksum")
+	}
+	return d, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	var answer []string
+	for k := range m {
+		answer = append(answer, k)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// reinstall offers to reinstall a broken plugin returning true if it was fixed
+func (o *DoctorOptions) reinstall(d *diagnosis) (bool, error) {
+	if d.plugin == nil {
+		log.Logger().Warnf("linked plugin %s => %s is broken; rebuild it or remove the link with: jx plugin unlink %s", termcolor.ColorInfo(d.name), d.path, strings.TrimPrefix(d.name, "jx-"))
+		return false, nil
+	}
+	if o.BatchMode {
+		log.Logger().Warnf("plugin %s version %s is broken; run jx plugin doctor without batch mode to reinstall it", termcolor.ColorInfo(d.name), d.version)
+		return false, nil
+	}
+	if o.Input == nil {
+		o.Input = survey.NewInput()
+	}
+	flag, err := o.Input.Confirm(fmt.Sprintf("would you like to reinstall plugin %s version %s", d.name, d.version), true, "removes the broken binary and downloads it again")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to confirm reinstall of plugin %s", d.name)
+	}
+	if !flag {
+		return false, nil
+	}
+
+	for _, path := range []string{d.path, d.path + plugins.ChecksumFileSuffix} {
+		err = os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return false, errors.Wrapf(err, "failed to remove %s", path)
+		}
+	}
+	path, err := plugins.EnsurePluginInstalled(*d.plugin, d.binDir)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to reinstall plugin %s", d.name)
+	}
+	problems := o.Doctor.Diagnose(path, d.version)
+	if len(problems) > 0 {
+		log.Logger().Errorf("reinstalled plugin %s is still broken: %s", d.name, strings.Join(problems, "; "))
+		return false, nil
+	}
+	log.Logger().Infof("reinstalled plugin %s version %s", termcolor.ColorInfo(d.name), termcolor.ColorInfo(d.version))
+	return true, nil
+}
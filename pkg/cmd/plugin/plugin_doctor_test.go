@@ -0,0 +1,105 @@
+package plugin_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/fake"
+	"github.com/jenkins-x/jx/pkg/cmd/plugin"
+	"github.com/jenkins-x/jx/pkg/common"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginDoctor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script plugins")
+	}
+	tmpDir, err := ioutil.TempDir("", "jx-plugin-doctor-")
+	require.NoError(t, err, "failed to create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	p := plugins.Plugins[0]
+	path := filepath.Join(tmpDir, fmt.Sprintf("%s-%s", p.Spec.Name, p.Spec.Version))
+	err = ioutil.WriteFile(path, []byte("#!/bin/sh\necho version: "+p.Spec.Version+"\n"), 0755)
+	require.NoError(t, err, "failed to write plugin")
+	require.NoError(t, plugins.WriteChecksum(path))
+
+	linked := filepath.Join(tmpDir, "jx-mine")
+	err = ioutil.WriteFile(linked, []byte("#!/bin/sh\necho hello\n"), 0755)
+	require.NoError(t, err, "failed to write linked plugin")
+
+	newOptions := func() *plugin.DoctorOptions {
+		_, o := plugin.NewCmdPluginDoctor()
+		o.Config = &config.Config{PluginLinks: map[string]string{"jx-mine": linked}}
+		o.PluginBinDir = tmpDir
+		o.BatchMode = true
+		o.Doctor.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+			data, err := ioutil.ReadFile(c.Name)
+			return string(data), err
+		}
+		return o
+	}
+
+	// a community plugin installed before checksums were recorded
+	community := filepath.Join(tmpDir, "jx-community-1.0.0")
+	err = ioutil.WriteFile(community, []byte("#!/bin/sh\necho version: 1.0.0\n"), 0755)
+	require.NoError(t, err, "failed to write community plugin")
+
+	err = newOptions().Run()
+	require.NoError(t, err, "healthy plugins should pass")
+
+	out := &bytes.Buffer{}
+	o := newOptions()
+	o.Args = []string{"community"}
+	o.Out = out
+	err = o.Run()
+	require.NoError(t, err, "a missing checksum should not fail")
+	assert.Contains(t, out.String(), "jx-community")
+	assert.Contains(t, out.String(), "no recorded checksum")
+
+	// a broken specific version of the plugin
+	versionDir := plugins.PluginVersionDir(tmpDir, "0.0.9")
+	require.NoError(t, os.MkdirAll(versionDir, 0755))
+	versionPath := filepath.Join(versionDir, p.Spec.Name+"-0.0.9")
+	err = ioutil.WriteFile(versionPath, []byte("#!/bin/sh\necho version: 0.0.8\n"), 0755)
+	require.NoError(t, err, "failed to write plugin version")
+	err = newOptions().Run()
+	require.Error(t, err, "should check the installed plugin versions")
+	require.NoError(t, os.RemoveAll(versionDir))
+
+	// lets corrupt the installed plugin
+	err = ioutil.WriteFile(path, []byte("#!/bin/sh\necho version: 0.0.1\n"), 0755)
+	require.NoError(t, err, "failed to write plugin")
+	err = newOptions().Run()
+	require.Error(t, err, "should fail in batch mode with a corrupt plugin")
+
+	// batch mode can be enabled via the environment
+	os.Setenv(common.EnvBatchMode, "true")
+	defer os.Unsetenv(common.EnvBatchMode)
+	o = newOptions()
+	o.BatchMode = false
+	input := &fake.FakeInput{}
+	o.Input = input
+	err = o.Run()
+	require.Error(t, err, "should fail in batch mode with a corrupt plugin")
+	assert.Equal(t, 0, input.Counter, "should not prompt to reinstall in batch mode")
+	os.Unsetenv(common.EnvBatchMode)
+
+	o = newOptions()
+	o.Args = []string{"mine"}
+	err = o.Run()
+	require.NoError(t, err, "should only check the linked plugin")
+
+	require.NoError(t, os.Chmod(linked, 0644))
+	err = o.Run()
+	assert.Error(t, err, "should fail for a non executable linked plugin")
+}
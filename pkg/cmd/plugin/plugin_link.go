@@ -0,0 +1,144 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLinkLong = templates.LongDesc(`
+		Links a local build of a plugin so that it is used instead of the managed plugin version.
+
+		A warning is displayed every time a linked plugin is used so that it is not left in place by accident.
+`)
+
+	cmdLinkExample = templates.Examples(`
+		# use a local build of the gitops plugin
+		jx plugin link gitops ~/go/src/github.com/jenkins-x-plugins/jx-gitops/build/jx-gitops
+	`)
+
+	cmdUnlinkLong = templates.LongDesc(`
+		Removes the link to a local build of a plugin so that the managed plugin version is used again
+`)
+
+	cmdUnlinkExample = templates.Examples(`
+		# stop using the local build of the gitops plugin
+		jx plugin unlink gitops
+	`)
+)
+
+// LinkOptions the options for linking a local plugin build
+type LinkOptions struct {
+	Config *config.Config
+	Args   []string
+}
+
+// UnlinkOptions the options for unlinking a local plugin build
+type UnlinkOptions struct {
+	Config *config.Config
+	Args   []string
+}
+
+// NewCmdPluginLink creates a command object for the command
+func NewCmdPluginLink() (*cobra.Command, *LinkOptions) {
+	o := &LinkOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "link NAME PATH",
+		Short:   "Links a local build of a plugin",
+		Long:    cmdLinkLong,
+		Example: cmdLinkExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd, o
+}
+
+// Run implements the command
+func (o *LinkOptions) Run() error {
+	if len(o.Args) != 2 {
+		return errors.Errorf("expected the plugin name and path arguments")
+	}
+	name := plugins.BinaryName(o.Args[0])
+	path, err := filepath.Abs(o.Args[1])
+	if err != nil {
+		return errors.Wrapf(err, "failed to find absolute path of %s", o.Args[1])
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find plugin binary %s", path)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return errors.Errorf("plugin binary %s is not an executable file", path)
+	}
+
+	if o.Config == nil {
+		o.Config, err = config.Load()
+		if err != nil {
+			return err
+		}
+	}
+	if o.Config.PluginLinks == nil {
+		o.Config.PluginLinks = map[string]string{}
+	}
+	o.Config.PluginLinks[name] = path
+	err = o.Config.Save()
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("linked plugin %s => %s", termcolor.ColorInfo(name), termcolor.ColorInfo(path))
+	return nil
+}
+
+// NewCmdPluginUnlink creates a command object for the command
+func NewCmdPluginUnlink() (*cobra.Command, *UnlinkOptions) {
+	o := &UnlinkOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "unlink NAME...",
+		Short:   "Removes the link to a local build of a plugin",
+		Long:    cmdUnlinkLong,
+		Example: cmdUnlinkExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd, o
+}
+
+// Run implements the command
+func (o *UnlinkOptions) Run() error {
+	if len(o.Args) == 0 {
+		return errors.Errorf("missing plugin name argument")
+	}
+	var err error
+	if o.Config == nil {
+		o.Config, err = config.Load()
+		if err != nil {
+			return err
+		}
+	}
+	for _, arg := range o.Args {
+		name := plugins.BinaryName(arg)
+		if o.Config.PluginLinks[name] == "" {
+			return errors.Errorf("plugin %s is not linked", name)
+		}
+		delete(o.Config.PluginLinks, name)
+		log.Logger().Infof("unlinked plugin %s", termcolor.ColorInfo(name))
+	}
+	return o.Config.Save()
+}
@@ -0,0 +1,61 @@
+package plugin_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/cmd/plugin"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginLinkAndUnlink(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "jx-plugin-link-")
+	require.NoError(t, err, "failed to create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	binary := filepath.Join(tmpDir, "jx-gitops")
+	err = ioutil.WriteFile(binary, []byte("#!/bin/sh\necho hello\n"), 0755)
+	require.NoError(t, err, "failed to write binary")
+
+	_, lo := plugin.NewCmdPluginLink()
+	lo.Args = []string{"gitops", binary}
+	err = lo.Run()
+	require.NoError(t, err, "failed to link plugin")
+
+	cfg, err := config.Load()
+	require.NoError(t, err, "failed to load config")
+	assert.Equal(t, binary, cfg.PluginLinks["jx-gitops"], "linked path")
+
+	out := &bytes.Buffer{}
+	_, listOptions := plugin.NewCmdPluginList()
+	listOptions.PluginBinDir = tmpDir
+	listOptions.Out = out
+	listOptions.Output, err = output.ParseFormat(`jsonpath={[?(@.name=="jx-gitops")].link}`)
+	require.NoError(t, err)
+	err = listOptions.Run()
+	require.NoError(t, err, "failed to list plugins")
+	assert.Equal(t, binary+"\n", out.String(), "listed link")
+
+	_, uo := plugin.NewCmdPluginUnlink()
+	uo.Args = []string{"jx-gitops"}
+	err = uo.Run()
+	require.NoError(t, err, "failed to unlink plugin")
+
+	cfg, err = config.Load()
+	require.NoError(t, err, "failed to load config")
+	assert.Empty(t, cfg.PluginLinks, "linked plugins")
+
+	_, lo = plugin.NewCmdPluginLink()
+	lo.Args = []string{"gitops", filepath.Join(tmpDir, "does-not-exist")}
+	err = lo.Run()
+	require.Error(t, err, "should fail to link a missing binary")
+}
@@ -0,0 +1,160 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdListLong = templates.LongDesc(`
+		Lists the plugins of the Jenkins X CLI, whether they are installed and any local builds which have been linked
+`)
+
+	cmdListExample = templates.Examples(`
+		# list the plugins
+		jx plugin list
+
+		# list the plugins as JSON
+		jx plugin list --output json
+	`)
+)
+
+// ListOptions the options for listing plugins
+type ListOptions struct {
+	Config       *config.Config
+	PluginBinDir string
+	Out          io.Writer
+	Output       *output.Format
+}
+
+// listedPlugin a plugin in the list
+type listedPlugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Status  string `json:"status"`
+	Link    string `json:"link,omitempty"`
+}
+
+// NewCmdPluginList creates a command object for the command
+func NewCmdPluginList() (*cobra.Command, *ListOptions) {
+	o := &ListOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Lists the plugins",
+		Aliases: []string{"ls"},
+		Long:    cmdListLong,
+		Example: cmdListExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			o.Output, err = output.FormatFromCommand(cmd)
+			helper.CheckErr(err)
+			o.Out = cmd.OutOrStdout()
+			err = o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd, o
+}
+
+// Run implements the command
+func (o *ListOptions) Run() error {
+	var err error
+	if o.Config == nil {
+		o.Config, err = config.Load()
+		if err != nil {
+			return err
+		}
+	}
+	if o.PluginBinDir == "" {
+		o.PluginBinDir, err = homedir.DefaultPluginBinDir()
+		if err != nil {
+			return errors.Wrap(err, "failed to find plugin bin directory")
+		}
+	}
+
+	var list []*listedPlugin
+	for i := range plugins.Plugins {
+		p := &plugins.Plugins[i]
+		name := p.Spec.Name
+		status, err := o.installStatus(name, p.Spec.Version)
+		if err != nil {
+			return err
+		}
+		list = append(list, &listedPlugin{
+			Name:    name,
+			Version: p.Spec.Version,
+			Status:  status,
+			Link:    o.Config.PluginLinks[name],
+		})
+	}
+
+	var names []string
+	for name := range o.Config.PluginLinks {
+		if plugins.PluginMap[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		list = append(list, &listedPlugin{
+			Name:   name,
+			Status: "linked",
+			Link:   o.Config.PluginLinks[name],
+		})
+	}
+
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.Output != nil {
+		var items []interface{}
+		for _, p := range list {
+			items = append(items, p)
+		}
+		r, err := output.NewResult("Plugin", []string{"name", "version", "status", "link"}, items...)
+		if err != nil {
+			return err
+		}
+		return output.Render(o.Out, o.Output, []output.Result{*r})
+	}
+
+	t := table.CreateTable(o.Out)
+	t.AddRow("NAME", "VERSION", "STATUS")
+	for _, p := range list {
+		status := p.Status
+		if p.Link != "" {
+			status = termcolor.ColorWarning(fmt.Sprintf("linked => %s", p.Link))
+		}
+		t.AddRow(p.Name, p.Version, status)
+	}
+	t.Render()
+	return nil
+}
+
+func (o *ListOptions) installStatus(name, version string) (string, error) {
+	path := filepath.Join(o.PluginBinDir, fmt.Sprintf("%s-%s", name, version))
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if exists {
+		return "installed", nil
+	}
+	return "not installed", nil
+}
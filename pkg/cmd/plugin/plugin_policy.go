@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/jenkins-x/jx/pkg/plugins/policy"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdPolicyCheckLong = templates.LongDesc(`
+		Checks whether a plugin is allowed by the plugin policies.
+
+		Policies are loaded from the system policy file, the file in $JX_PLUGIN_POLICY and the plugin-policy.yaml file in your jx home dir. The system rules are evaluated first and the first matching rule wins; the $JX_PLUGIN_POLICY file and the file in your jx home dir can only deny plugins which the system policy allows.
+`)
+
+	cmdPolicyCheckExample = templates.Examples(`
+		# check if the gitops plugin is allowed
+		jx plugin policy check gitops
+
+		# check if a specific version of a plugin is allowed
+		jx plugin policy check foo --version 1.2.3 --source github.com/jenkins-x-plugins/jx-foo
+	`)
+)
+
+// PolicyCheckOptions the options for checking a plugin against the policies
+type PolicyCheckOptions struct {
+	Policies *policy.Policies
+	Args     []string
+	Version  string
+	Source   string
+}
+
+// NewCmdPluginPolicy creates the command group for plugin policies
+func NewCmdPluginPolicy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Commands for the policies which allow or deny plugins",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := cmd.Help()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(cobras.SplitCommand(NewCmdPluginPolicyCheck()))
+	return cmd
+}
+
+// NewCmdPluginPolicyCheck creates a command object for the command
+func NewCmdPluginPolicyCheck() (*cobra.Command, *PolicyCheckOptions) {
+	o := &PolicyCheckOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "check NAME",
+		Short:   "Checks whether a plugin is allowed by the plugin policies",
+		Long:    cmdPolicyCheckLong,
+		Example: cmdPolicyCheckExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "The version of the plugin to check. Defaults to the version of the default plugin")
+	cmd.Flags().StringVarP(&o.Source, "source", "s", "", "The source of the plugin to check. Defaults to the source of the default plugin")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *PolicyCheckOptions) Run() error {
+	if len(o.Args) != 1 {
+		return errors.Errorf("expected a single plugin name argument")
+	}
+	var err error
+	if o.Policies == nil {
+		o.Policies, err = policy.Load()
+		if err != nil {
+			return errors.Wrap(err, "failed to load plugin policies")
+		}
+	}
+
+	p := policy.Plugin{
+		Name:    plugins.BinaryName(o.Args[0]),
+		Version: o.Version,
+		Source:  o.Source,
+	}
+	if defaultPlugin := plugins.PluginMap[p.Name]; defaultPlugin != nil {
+		if p.Version == "" {
+			p.Version = defaultPlugin.Spec.Version
+		}
+		if p.Source == "" {
+			p.Source = plugins.PluginSource(defaultPlugin)
+		}
+	}
+
+	if len(o.Policies.Files) == 0 {
+		log.Logger().Infof("no plugin policy files found")
+	}
+	for _, f := range o.Policies.Files {
+		log.Logger().Infof("loaded plugin policy %s", termcolor.ColorInfo(f))
+	}
+	log.Logger().Infof("plugin: %s version: %s source: %s", termcolor.ColorInfo(p.Name), termcolor.ColorInfo(p.Version), termcolor.ColorInfo(p.Source))
+
+	d := o.Policies.Check(p)
+	if d.Rule != nil {
+		log.Logger().Infof("matched rule: %s", d.Rule.String())
+	}
+	if !d.Allowed {
+		return errors.Errorf("plugin %s is denied by %s", p.Name, d.Reason())
+	}
+	log.Logger().Infof("plugin %s is %s by %s", termcolor.ColorInfo(p.Name), termcolor.ColorInfo("allowed"), d.Reason())
+	return nil
+}
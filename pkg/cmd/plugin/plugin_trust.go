@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"os"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdTrustListLong = templates.LongDesc(`
+		Lists the community plugins you have approved to be downloaded and run by the Jenkins X CLI
+`)
+
+	cmdTrustListExample = templates.Examples(`
+		# list the trusted community plugins
+		jx plugin trust list
+	`)
+
+	cmdTrustRevokeLong = templates.LongDesc(`
+		Revokes the trust of community plugins so that you are prompted again before they are next used
+`)
+
+	cmdTrustRevokeExample = templates.Examples(`
+		# revoke the trust of the jx-foo plugin
+		jx plugin trust revoke foo
+	`)
+)
+
+// TrustListOptions the options for listing trusted plugins
+type TrustListOptions struct {
+	TrustStore *plugins.TrustStore
+}
+
+// TrustRevokeOptions the options for revoking trusted plugins
+type TrustRevokeOptions struct {
+	TrustStore *plugins.TrustStore
+	Args       []string
+}
+
+// NewCmdPluginTrustList creates a command object for the command
+func NewCmdPluginTrustList() (*cobra.Command, *TrustListOptions) {
+	o := &TrustListOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Lists the trusted community plugins",
+		Aliases: []string{"ls"},
+		Long:    cmdTrustListLong,
+		Example: cmdTrustListExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd, o
+}
+
+// Run implements the command
+func (o *TrustListOptions) Run() error {
+	var err error
+	if o.TrustStore == nil {
+		o.TrustStore, err = plugins.LoadTrustStore()
+		if err != nil {
+			return err
+		}
+	}
+	if len(o.TrustStore.Plugins) == 0 {
+		log.Logger().Infof("no community plugins have been trusted")
+		return nil
+	}
+	t := table.CreateTable(os.Stdout)
+	t.AddRow("NAME", "VERSION", "REPOSITORY", "APPROVED")
+	for _, p := range o.TrustStore.Plugins {
+		t.AddRow(p.Name, p.Version, p.Repository, p.ApprovedAt.Format("2006-01-02 15:04:05"))
+	}
+	t.Render()
+	return nil
+}
+
+// NewCmdPluginTrustRevoke creates a command object for the command
+func NewCmdPluginTrustRevoke() (*cobra.Command, *TrustRevokeOptions) {
+	o := &TrustRevokeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "revoke NAME...",
+		Short:   "Revokes the trust of community plugins",
+		Aliases: []string{"rm", "delete"},
+		Long:    cmdTrustRevokeLong,
+		Example: cmdTrustRevokeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd, o
+}
+
+// Run implements the command
+func (o *TrustRevokeOptions) Run() error {
+	if len(o.Args) == 0 {
+		return errors.Errorf("missing plugin name argument")
+	}
+	var err error
+	if o.TrustStore == nil {
+		o.TrustStore, err = plugins.LoadTrustStore()
+		if err != nil {
+			return err
+		}
+	}
+	for _, arg := range o.Args {
+		name := plugins.BinaryName(arg)
+		if !o.TrustStore.Revoke(name) {
+			return errors.Errorf("plugin %s is not trusted", name)
+		}
+		log.Logger().Infof("revoked trust of plugin %s", termcolor.ColorInfo(name))
+	}
+	return o.TrustStore.Save()
+}
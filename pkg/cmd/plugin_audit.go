@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os/exec"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/audit"
+	"github.com/jenkins-x/jx/pkg/plugins"
+)
+
+// recordAudit writes an audit log entry for the plugin invocation.
+// Errors other than the exit code of the plugin such as a pre hook denying it are recorded in the entry
+func recordAudit(auditLog *audit.Log, r *plugins.Resolution, cmdArgs []string, start time.Time, err error) {
+	kubeContext, ns := audit.KubeContext()
+	e := &audit.Entry{
+		Timestamp:      start.UTC(),
+		User:           audit.CurrentUser(),
+		Context:        kubeContext,
+		Namespace:      ns,
+		Plugin:         r.Name,
+		Version:        r.Version,
+		Path:           r.Path,
+		Args:           audit.Redact(cmdArgs),
+		DurationMillis: time.Since(start).Milliseconds(),
+		ExitCode:       exitCode(err),
+	}
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		e.Error = err.Error()
+	}
+	err = auditLog.Write(e)
+	if err != nil {
+		log.Logger().Warnf("failed to write the audit log: %s", err.Error())
+	}
+}
+
+// exitCode returns the process exit code for the error from running a command
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/jenkins-x/jx/pkg/plugins/policy"
+	"github.com/pkg/errors"
+)
+
+// verifyPluginPolicy returns an error if the plugin is denied by the plugin policies
+func verifyPluginPolicy(plugin *jenkinsv1.Plugin) error {
+	return verifyPolicy(policy.Plugin{
+		Name:    plugin.Spec.Name,
+		Version: plugin.Spec.Version,
+		Source:  plugins.PluginSource(plugin),
+	})
+}
+
+// verifyLocalPluginPolicy returns an error if a plugin binary found on the local file system is denied by the plugin policies
+func verifyLocalPluginPolicy(name, path string) error {
+	return verifyPolicy(policy.Plugin{
+		Name:   name,
+		Source: "file://" + path,
+	})
+}
+
+func verifyPolicy(plugin policy.Plugin) error {
+	policies, err := policy.Load()
+	if err != nil {
+		return errors.Wrapf(err, "failed to load plugin policies")
+	}
+	return policies.Verify(plugin)
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/jenkins-x/jx/pkg/plugins/policy"
+	"github.com/pkg/errors"
+)
+
+// resolvePlugin finds the plugin for the given command line arguments using the longest matching
+// `jx-<words>` name. The versions are any specific plugin versions requested indexed by plugin name.
+// If install is false no plugins are downloaded.
+// Returns nil if no plugin could be found
+func resolvePlugin(pluginHandler PluginHandler, cmdArgs []string, versions map[string]string, pluginBinDir string, install bool) (*plugins.Resolution, error) {
+	var remainingArgs []string // all "non-flag" arguments
+
+	for idx := range cmdArgs {
+		if strings.HasPrefix(cmdArgs[idx], "-") {
+			break
+		}
+		remainingArgs = append(remainingArgs, strings.Replace(cmdArgs[idx], "-", "_", -1))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load jx config")
+	}
+	catalog, err := plugins.CatalogPlugins(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the current working directory")
+	}
+	projectPluginDir, err := plugins.FindProjectPluginDir(wd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find project plugin dir")
+	}
+
+	// lets create the candidates starting at the longest possible name with given cmdArgs
+	var candidates []*plugins.Resolution
+	for ; len(remainingArgs) > 0; remainingArgs = remainingArgs[:len(remainingArgs)-1] {
+		candidates = append(candidates, &plugins.Resolution{
+			Name: fmt.Sprintf("jx-%s", strings.Join(remainingArgs, "-")),
+			Args: cmdArgs[len(remainingArgs):],
+		})
+	}
+
+	// lets use any specific versions of plugins requested first
+	for _, r := range candidates {
+		version := versions[r.Name]
+		if version == "" {
+			continue
+		}
+		r.Kind = plugins.ResolutionVersion
+		r.Version = version
+		if !install {
+			r.Path = filepath.Join(plugins.PluginVersionDir(pluginBinDir, version), fmt.Sprintf("%s-%s", r.Name, version))
+			return r, nil
+		}
+		vh, ok := pluginHandler.(versionedPluginHandler)
+		if !ok {
+			return nil, errors.Errorf("cannot install version %s of plugin %s", version, r.Name)
+		}
+		r.Path, err = vh.LookupVersion(r.Name, version, pluginBinDir)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	// lets check all the local candidates first so that multi-word commands don't need any remote lookups
+	for _, r := range candidates {
+		found, err := resolveLocalCandidate(pluginHandler, r, cfg, catalog, projectPluginDir, pluginBinDir, install)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return r, nil
+		}
+	}
+	if !install {
+		return nil, nil
+	}
+
+	// now lets try find a community plugin
+	for _, r := range candidates {
+		path, err := pluginHandler.Lookup(r.Name, pluginBinDir)
+		if isNotTrusted(err) || policy.IsDenied(err) {
+			return nil, err
+		}
+		if err == nil && path != "" {
+			r.Path = path
+			r.Kind = plugins.ResolutionLocal
+			return r, nil
+		}
+	}
+
+	// lets fall back to any installed community plugin we could not refresh
+	for _, r := range candidates {
+		found, err := resolveInstalledPlugin(pluginHandler, r, pluginBinDir, install, true)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// resolveLocalCandidate resolves the plugin for the candidate without any remote lookups returning true if it was found
+func resolveLocalCandidate(pluginHandler PluginHandler, r *plugins.Resolution, cfg *config.Config, catalog []jenkinsv1.Plugin, projectPluginDir, pluginBinDir string, install bool) (bool, error) {
+	commandName := r.Name
+
+	// lets use any explicitly linked local build of the plugin first
+	if linkPath := cfg.PluginLinks[commandName]; linkPath != "" {
+		err := verifyLocalPluginPolicy(commandName, linkPath)
+		if err != nil {
+			return false, err
+		}
+		r.Path = linkPath
+		r.Kind = plugins.ResolutionLinked
+		return true, nil
+	}
+
+	// lets see if there's a local build of the plugin on the PATH for developers...
+	localPath, err := exec.LookPath(commandName)
+	if err == nil && localPath != "" {
+		err = verifyLocalPluginPolicy(commandName, localPath)
+		if err != nil {
+			return false, err
+		}
+		r.Path = localPath
+		r.Kind = plugins.ResolutionLocal
+		return true, nil
+	}
+
+	// lets try the correct plugin versions next
+	for i := range catalog {
+		p := catalog[i]
+		if p.Spec.Name != commandName {
+			continue
+		}
+		err = verifyPluginPolicy(&p)
+		if err != nil {
+			return false, err
+		}
+		r.Kind = plugins.ResolutionDefault
+		r.Version = p.Spec.Version
+		if !install {
+			r.Path = filepath.Join(pluginBinDir, fmt.Sprintf("%s-%s", commandName, p.Spec.Version))
+			return true, nil
+		}
+		r.Path, err = plugins.EnsurePluginInstalled(p, pluginBinDir)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to install binary plugin %s version %s to %s", commandName, p.Spec.Version, pluginBinDir)
+		}
+		return r.Path != "", nil
+	}
+
+	// then any project specific plugins
+	r.Path, err = plugins.FindProjectPlugin(projectPluginDir, commandName)
+	if err != nil {
+		return false, err
+	}
+	if r.Path != "" {
+		err = verifyLocalPluginPolicy(commandName, r.Path)
+		if err != nil {
+			return false, err
+		}
+		r.Kind = plugins.ResolutionProject
+		return true, nil
+	}
+
+	// finally any community plugin which was previously installed
+	return resolveInstalledPlugin(pluginHandler, r, pluginBinDir, install, false)
+}
+
+// trustVerifier is implemented by plugin handlers which verify community plugins have been trusted
+type trustVerifier interface {
+	verifyTrusted(plugin *jenkinsv1.Plugin) error
+}
+
+// resolveInstalledPlugin resolves a community plugin which was previously installed into the plugin bin dir
+// checking it against the plugin policies and the trust store. Unless allowStale is true the plugin is not
+// used if it was installed more than plugins.CommunityPluginRefreshInterval ago so that newer releases are found
+func resolveInstalledPlugin(pluginHandler PluginHandler, r *plugins.Resolution, pluginBinDir string, install, allowStale bool) (bool, error) {
+	path, version, err := plugins.FindInstalledPlugin(pluginBinDir, r.Name)
+	if err != nil {
+		return false, err
+	}
+	if path == "" {
+		return false, nil
+	}
+	plugin := extensions.CreateJXPlugin("jenkins-x-plugins", strings.TrimPrefix(r.Name, "jx-"), version)
+	err = verifyPluginPolicy(&plugin)
+	if err != nil {
+		return false, err
+	}
+	if install {
+		tv, ok := pluginHandler.(trustVerifier)
+		if !ok {
+			// lets leave it to the plugin handler to look up the plugin
+			return false, nil
+		}
+		if !allowStale {
+			info, err := os.Stat(path)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to stat %s", path)
+			}
+			if time.Since(info.ModTime()) > plugins.CommunityPluginRefreshInterval {
+				log.Logger().Debugf("checking for a newer release of community plugin %s than %s", r.Name, version)
+				return false, nil
+			}
+		}
+		err = tv.verifyTrusted(&plugin)
+		if err != nil {
+			return false, err
+		}
+	}
+	r.Path = path
+	r.Kind = plugins.ResolutionLocal
+	r.Version = version
+	return true, nil
+}
+
+// logResolution logs how the plugin was resolved warning if a linked plugin is being used
+func logResolution(r *plugins.Resolution) {
+	if r.Kind == plugins.ResolutionLinked {
+		log.Logger().Warnf("using linked plugin %s => %s (remove with: jx plugin unlink %s)", r.Name, r.Path, strings.TrimPrefix(r.Name, "jx-"))
+	}
+}
+
+// projectPluginCommandGroups returns the help group for any project plugins in the current directory tree
+func projectPluginCommandGroups() templates.PluginCommandGroups {
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Logger().Debugf("failed to find the current working directory: %s", err.Error())
+		return nil
+	}
+	dir, err := plugins.FindProjectPluginDir(wd)
+	if err != nil || dir == "" {
+		return nil
+	}
+	paths, err := plugins.ProjectPlugins(dir)
+	if err != nil {
+		log.Logger().Debugf("failed to find project plugins: %s", err.Error())
+		return nil
+	}
+	group := templates.PluginCommandGroup{
+		Message: "Project Commands:",
+	}
+	for _, path := range paths {
+		subCommand := strings.TrimPrefix(strings.Replace(plugins.ProjectPluginName(path), "-", " ", -1), "jx ")
+		group.Commands = append(group.Commands, &templates.PluginCommand{
+			PluginSpec: jenkinsv1.PluginSpec{
+				SubCommand:  subCommand,
+				Description: path,
+			},
+		})
+	}
+	if len(group.Commands) == 0 {
+		return nil
+	}
+	return templates.PluginCommandGroups{group}
+}
@@ -0,0 +1,190 @@
+package cmd_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/cmd"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTransport fails all requests with a 404 recording how many were made
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Status:     "404 Not Found",
+		Body:       ioutil.NopCloser(strings.NewReader(`{"message": "Not Found"}`)),
+		Request:    req,
+	}, nil
+}
+
+// setupResolveTest creates a jx home with the default gitops plugin installed and an empty $PATH
+func setupResolveTest(t testing.TB) (*cmd.RootOptions, *countingTransport, func()) {
+	tmpDir, err := ioutil.TempDir("", "test-resolve-")
+	require.NoError(t, err)
+	oldPath := os.Getenv("PATH")
+	os.Setenv("JX3_HOME", tmpDir)
+	os.Setenv("PATH", filepath.Join(tmpDir, "path"))
+	cleanup := func() {
+		os.Unsetenv("JX3_HOME")
+		os.Setenv("PATH", oldPath)
+		os.RemoveAll(tmpDir)
+	}
+
+	pluginBinDir := filepath.Join(tmpDir, "plugins", "bin")
+	require.NoError(t, os.MkdirAll(pluginBinDir, 0755))
+	gitops := plugins.PluginMap["jx-gitops"]
+	require.NotNil(t, gitops, "no jx-gitops plugin in the catalog")
+	err = ioutil.WriteFile(filepath.Join(pluginBinDir, "jx-gitops-"+gitops.Spec.Version), []byte("#!/bin/sh\n"), 0755)
+	require.NoError(t, err)
+
+	transport := &countingTransport{}
+	o := &cmd.RootOptions{
+		PluginBinDir: pluginBinDir,
+		HTTPClient:   &http.Client{Transport: transport},
+		Environ:      []string{"JX_BATCH_MODE=true"},
+	}
+	return o, transport, cleanup
+}
+
+func TestResolveCatalogPluginWithoutRemoteLookups(t *testing.T) {
+	o, transport, cleanup := setupResolveTest(t)
+	defer cleanup()
+
+	r, err := o.Resolve([]string{"gitops", "helmfile", "resolve", "--batch-mode"})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "jx-gitops", r.Name)
+	assert.Equal(t, plugins.ResolutionDefault, r.Kind)
+	assert.Equal(t, []string{"helmfile", "resolve", "--batch-mode"}, r.Args)
+	assert.Equal(t, int32(0), transport.requests, "should not make any remote lookups for a catalog plugin")
+}
+
+func TestResolveCachesMissingPlugins(t *testing.T) {
+	o, transport, cleanup := setupResolveTest(t)
+	defer cleanup()
+
+	r, err := o.Resolve([]string{"doesnotexist", "something"})
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	assert.Equal(t, int32(2), transport.requests, "should look up jx-doesnotexist-something and jx-doesnotexist")
+
+	r, err = o.Resolve([]string{"doesnotexist", "something"})
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	assert.Equal(t, int32(2), transport.requests, "should use the not found cache")
+}
+
+func BenchmarkResolveCatalogPlugin(b *testing.B) {
+	o, transport, cleanup := setupResolveTest(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, err := o.Resolve([]string{"gitops", "helmfile", "resolve"})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if r == nil || r.Kind != plugins.ResolutionDefault {
+			b.Fatalf("failed to resolve the catalog plugin: %#v", r)
+		}
+	}
+	if transport.requests > 0 {
+		b.Fatalf("made %d remote lookups resolving a catalog plugin", transport.requests)
+	}
+}
+
+func TestResolvePluginVersion(t *testing.T) {
+	o, transport, cleanup := setupResolveTest(t)
+	defer cleanup()
+
+	// lets fake an install of the older version
+	versionDir := plugins.PluginVersionDir(o.PluginBinDir, "0.2.1")
+	require.NoError(t, os.MkdirAll(versionDir, 0755))
+	expectedPath := filepath.Join(versionDir, "jx-gitops-0.2.1")
+	require.NoError(t, ioutil.WriteFile(expectedPath, []byte("#!/bin/sh\n"), 0755))
+
+	for _, args := range [][]string{
+		{"gitops@0.2.1", "lint", "--dir", "foo"},
+		{"--plugin-version", "gitops=0.2.1", "gitops", "lint", "--dir", "foo"},
+		{"--plugin-version=jx-gitops=v0.2.1", "gitops", "lint", "--dir", "foo"},
+	} {
+		r, err := o.Resolve(args)
+		require.NoError(t, err, "for args %v", args)
+		require.NotNil(t, r, "for args %v", args)
+		assert.Equal(t, plugins.ResolutionVersion, r.Kind, "for args %v", args)
+		assert.Equal(t, "0.2.1", r.Version, "for args %v", args)
+		assert.Equal(t, expectedPath, r.Path, "for args %v", args)
+		assert.Equal(t, []string{"lint", "--dir", "foo"}, r.Args, "for args %v", args)
+	}
+
+	// the default version should not change
+	r, err := o.Resolve([]string{"gitops", "lint"})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, plugins.ResolutionDefault, r.Kind)
+	assert.Equal(t, plugins.PluginMap["jx-gitops"].Spec.Version, r.Version)
+	assert.FileExists(t, r.Path)
+	assert.Equal(t, int32(0), transport.requests)
+
+	_, err = o.Resolve([]string{"gitops@latest", "lint"})
+	require.Error(t, err)
+}
+
+func TestResolveInstalledCommunityPluginIsTrusted(t *testing.T) {
+	o, transport, cleanup := setupResolveTest(t)
+	defer cleanup()
+
+	path := filepath.Join(o.PluginBinDir, "jx-foo-1.0.0")
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+
+	_, err := o.Resolve([]string{"foo", "bar"})
+	require.Error(t, err, "should not run an untrusted community plugin")
+	assert.Contains(t, err.Error(), "has not been trusted")
+
+	store, err := plugins.LoadTrustStore()
+	require.NoError(t, err)
+	store.Trust(plugins.TrustedPlugin{Name: "jx-foo", Repository: "https://github.com/someone-else/jx-foo", Version: "1.0.0"})
+	require.NoError(t, store.Save())
+
+	_, err = o.Resolve([]string{"foo", "bar"})
+	require.Error(t, err, "should not run a plugin trusted from a different repository")
+	assert.Contains(t, err.Error(), "has not been trusted")
+
+	store.Trust(plugins.TrustedPlugin{Name: "jx-foo", Repository: "https://github.com/jenkins-x-plugins/jx-foo", Version: "1.0.0"})
+	require.NoError(t, store.Save())
+
+	r, err := o.Resolve([]string{"foo", "bar"})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, path, r.Path)
+	assert.Equal(t, "1.0.0", r.Version)
+	assert.Equal(t, int32(0), transport.requests, "should not make any remote lookups for a recently installed plugin")
+
+	// lets check for a newer release once the installed plugin is stale
+	old := time.Now().Add(-2 * plugins.CommunityPluginRefreshInterval)
+	require.NoError(t, os.Chtimes(path, old, old))
+	r, err = o.Resolve([]string{"foo", "bar"})
+	require.NoError(t, err)
+	require.NotNil(t, r, "should fall back to the installed plugin")
+	assert.Equal(t, path, r.Path)
+	assert.Equal(t, int32(2), transport.requests, "should look up jx-foo-bar and jx-foo")
+
+	store.Revoke("jx-foo")
+	require.NoError(t, store.Save())
+	_, err = o.Resolve([]string{"foo", "bar"})
+	require.Error(t, err, "should not run a revoked community plugin")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/survey"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/jenkins-x/jx/pkg/plugins/oci"
+	"github.com/pkg/errors"
+)
+
+// notTrustedError is returned when a community plugin has not been trusted
+type notTrustedError struct {
+	message string
+}
+
+func (e *notTrustedError) Error() string {
+	return e.message
+}
+
+// isNotTrusted returns true if the error is caused by a plugin not being trusted
+func isNotTrusted(err error) bool {
+	_, ok := errors.Cause(err).(*notTrustedError)
+	return ok
+}
+
+// pluginRepository returns the repository the plugin binaries are downloaded from so that a trusted
+// plugin is not trusted again if it is released from somewhere else
+func pluginRepository(plugin *jenkinsv1.Plugin) (string, error) {
+	source := plugins.PluginSource(plugin)
+	if source == "" {
+		return "", errors.Errorf("failed to find the repository plugin %s is downloaded from", plugin.Spec.Name)
+	}
+	for _, b := range plugin.Spec.Binaries {
+		if oci.IsReference(b.URL) {
+			return "oci://" + source, nil
+		}
+	}
+	return "https://" + source, nil
+}
+
+// verifyTrusted checks that a community plugin which is not part of the default plugins has been
+// approved by the user before it is downloaded and executed for the first time
+func (h *localPluginHandler) verifyTrusted(plugin *jenkinsv1.Plugin) error {
+	name := plugin.Spec.Name
+	repository, err := pluginRepository(plugin)
+	if err != nil {
+		return err
+	}
+
+	store, err := plugins.LoadTrustStore()
+	if err != nil {
+		return err
+	}
+	if store.IsTrusted(name, repository) {
+		return nil
+	}
+
+	u, err := extensions.FindPluginUrl(plugin.Spec)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find download URL for plugin %s", name)
+	}
+	trusted := plugins.TrustedPlugin{
+		Name:       name,
+		Repository: repository,
+		Version:    plugin.Spec.Version,
+		URL:        u,
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.IsPluginAllowed(name) {
+		log.Logger().Debugf("plugin %s is in the plugin allow list", name)
+	} else {
+		if h.BatchMode {
+			return &notTrustedError{
+				message: fmt.Sprintf("community plugin %s version %s from %s has not been trusted. Add it to pluginAllowlist in your jx config or $%s to use it in batch mode",
+					name, plugin.Spec.Version, repository, config.EnvPluginAllowlist),
+			}
+		}
+		if h.Input == nil {
+			h.Input = survey.NewInput()
+		}
+		log.Logger().Infof("the command is provided by the community plugin %s which has not been used before:", termcolor.ColorInfo(name))
+		log.Logger().Infof("  repository: %s", termcolor.ColorInfo(repository))
+		log.Logger().Infof("  version:    %s", termcolor.ColorInfo(plugin.Spec.Version))
+		log.Logger().Infof("  download:   %s", termcolor.ColorInfo(u))
+
+		message := fmt.Sprintf("Do you trust the plugin %s and want to download and run it:", name)
+		help := "community plugins are not verified by Jenkins X; only trust plugins from repositories you know"
+		flag, err := h.Input.Confirm(message, false, help)
+		if err != nil {
+			return errors.Wrapf(err, "failed to confirm trust of plugin %s", name)
+		}
+		if !flag {
+			return &notTrustedError{message: fmt.Sprintf("plugin %s was not trusted", name)}
+		}
+	}
+
+	store.Trust(trusted)
+	err = store.Save()
+	if err != nil {
+		return errors.Wrapf(err, "failed to record trust of plugin %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+)
+
+const (
+	pluginVersionFlag = "--plugin-version"
+	outputFlag        = "--" + output.FlagName
+)
+
+// versionedPluginHandler a PluginHandler which can install specific versions of plugins
+type versionedPluginHandler interface {
+	// LookupVersion returns the path to the given version of the plugin installing it if required
+	LookupVersion(filename, version, pluginBinDir string) (string, error)
+}
+
+// LookupVersion implements versionedPluginHandler
+func (h *localPluginHandler) LookupVersion(filename, version, pluginBinDir string) (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load jx config")
+	}
+	catalog, err := plugins.CatalogPlugins(cfg)
+	if err != nil {
+		return "", err
+	}
+	var plugin jenkinsv1.Plugin
+	found := false
+	for i := range catalog {
+		if catalog[i].Spec.Name == filename {
+			plugin, err = plugins.PluginVersion(catalog[i], version)
+			if err != nil {
+				return "", err
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		plugin = plugins.CreateCommunityPlugin(filename, version)
+	}
+	err = verifyPluginPolicy(&plugin)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		err = h.verifyTrusted(&plugin)
+		if err != nil {
+			return "", err
+		}
+	}
+	path, err := plugins.EnsurePluginVersionInstalled(plugin, pluginBinDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to install plugin %s version %s", filename, version)
+	}
+	return path, nil
+}
+
+// rootArgs the arguments parsed by the root command before dispatching to a plugin
+type rootArgs struct {
+	// Args the remaining arguments
+	Args []string
+	// Versions any specific plugin versions requested indexed by plugin binary name
+	Versions map[string]string
+	// Output the output format if specified
+	Output string
+}
+
+// parseRootArgs removes any leading `--plugin-version name=version` and `--output format` flags and
+// a `name@version` command from the arguments
+func parseRootArgs(args []string) (*rootArgs, error) {
+	answer := &rootArgs{
+		Versions: map[string]string{},
+	}
+	addVersion := func(name, version string) error {
+		name = strings.TrimSpace(name)
+		version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+		if name == "" || version == "" {
+			return errors.Errorf("invalid plugin version %s@%s; expected name@version", name, version)
+		}
+		if _, err := semver.Parse(version); err != nil {
+			return errors.Wrapf(err, "invalid version %s for plugin %s", version, name)
+		}
+		answer.Versions[plugins.BinaryName(name)] = version
+		return nil
+	}
+
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		flag := arg
+		value := ""
+		hasValue := false
+		if idx := strings.Index(arg, "="); idx > 0 && strings.HasPrefix(arg, "--") {
+			flag = arg[:idx]
+			value = arg[idx+1:]
+			hasValue = true
+		}
+		if flag != pluginVersionFlag && flag != outputFlag {
+			break
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, errors.Errorf("missing value for %s", flag)
+			}
+			i++
+			value = args[i]
+		}
+		if flag == outputFlag {
+			answer.Output = value
+			continue
+		}
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid %s value %s; expected name=version", pluginVersionFlag, value)
+		}
+		err := addVersion(parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	answer.Args = append([]string{}, args[i:]...)
+
+	if len(answer.Args) > 0 && !strings.HasPrefix(answer.Args[0], "-") {
+		if idx := strings.Index(answer.Args[0], "@"); idx >= 0 {
+			err := addVersion(answer.Args[0][:idx], answer.Args[0][idx+1:])
+			if err != nil {
+				return nil, err
+			}
+			answer.Args[0] = answer.Args[0][:idx]
+		}
+	}
+	return answer, nil
+}
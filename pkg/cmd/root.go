@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
@@ -19,28 +22,92 @@ import (
 	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/httphelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/audit"
+	auditcmd "github.com/jenkins-x/jx/pkg/cmd/audit"
 	"github.com/jenkins-x/jx/pkg/cmd/dashboard"
 	"github.com/jenkins-x/jx/pkg/cmd/namespace"
+	"github.com/jenkins-x/jx/pkg/cmd/plugin"
 	"github.com/jenkins-x/jx/pkg/cmd/ui"
 	"github.com/jenkins-x/jx/pkg/cmd/upgrade"
 	"github.com/jenkins-x/jx/pkg/cmd/version"
+	"github.com/jenkins-x/jx/pkg/cmd/which"
+	"github.com/jenkins-x/jx/pkg/common"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/hooks"
+	"github.com/jenkins-x/jx/pkg/output"
 	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/jenkins-x/jx/pkg/plugins/host"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/util/json"
 )
 
-// Main creates the new command
-func Main(args []string) *cobra.Command {
+// RootOptions the options for creating and executing the root jx command. Creating the command
+// has no side effects; plugins are only resolved, installed and executed by Execute
+type RootOptions struct {
+	// In the standard input. Defaults to os.Stdin
+	In io.Reader
+	// Out the standard output. Defaults to os.Stdout
+	Out io.Writer
+	// Err the standard error. Defaults to os.Stderr
+	Err io.Writer
+	// Environ the environment passed to plugins. Defaults to os.Environ()
+	Environ []string
+	// PluginHandler finds and executes plugins. Defaults to plugins in the plugin bin dir or on the $PATH
+	PluginHandler PluginHandler
+	// PluginBinDir the directory plugins are installed into. Defaults to ~/.jx3/plugins/bin
+	PluginBinDir string
+	// HTTPClient the client used to find community plugins
+	HTTPClient *http.Client
+	// Exit exits with the exit code of a plugin which failed. Defaults to os.Exit
+	Exit func(code int)
+	// Output the output format for commands and plugins which support structured output
+	Output string
+
+	// Cmd the root command created by NewCmdRoot
+	Cmd *cobra.Command
+
+	po                     *templates.Options
+	getPluginCommandGroups func() (templates.PluginCommandGroups, bool)
+	config                 *config.Config
+	hooks                  *hooks.Runner
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Jenkins X 3.x alpha command line
+
+		Commands which are not built in are run as plugins. If no command or plugin matches, jx suggests the closest
+		built-in commands, aliases and linked, catalog, project, installed and trusted plugins, saying whether each
+		plugin is installed or blocked by the plugin policies.
+
+		Suggestions from a synced plugin index, the subcommands of plugins and user defined aliases are not supported
+		yet as jx has none of these; they are planned as a follow-up.
+`)
+)
+
+// NewCmdRoot creates the root jx command without resolving or running any plugins
+func NewCmdRoot(o *RootOptions) *cobra.Command {
+	o.defaults()
 	cmd := &cobra.Command{
 		Use:   "jx",
 		Short: "Jenkins X 3.x alpha command line",
+		Long:  cmdLong,
 		Run:   runHelp,
 	}
+	cmd.SetIn(o.In)
+	cmd.SetOut(o.Out)
+	cmd.SetErr(o.Err)
+	// the plugin versions are parsed by Execute before the command runs; the flag is here to document it
+	output.AddFlag(cmd, &o.Output)
+	cmd.Flags().StringArray("plugin-version", nil, "Runs a specific version of a plugin such as 'gitops=0.2.1' without changing its default version. Can also be specified as: jx gitops@0.2.1")
+	o.Cmd = cmd
 
 	po := &templates.Options{}
+	o.po = po
 	getPluginCommandGroups := func() (templates.PluginCommandGroups, bool) {
 		verifier := &extensions.CommandOverrideVerifier{
 			Root:        cmd,
@@ -50,18 +117,27 @@ func Main(args []string) *cobra.Command {
 		if err != nil {
 			log.Logger().Errorf("%v", err)
 		}
+		pluginCommandGroups = append(pluginCommandGroups, projectPluginCommandGroups()...)
 		return pluginCommandGroups, po.ManagedPluginsEnabled
 	}
-	doCmd := func(cmd *cobra.Command, args []string) {
-		handleCommand(po, cmd, args, getPluginCommandGroups)
+	o.getPluginCommandGroups = getPluginCommandGroups
+	doCmd := func(_ *cobra.Command, args []string) {
+		_, err := o.dispatchPlugin(args[1:], nil)
+		if err != nil {
+			log.Logger().Errorf("%v", err)
+			o.Exit(1)
+		}
 	}
 
 	generalCommands := []*cobra.Command{
+		cobras.SplitCommand(auditcmd.NewCmdAudit()),
 		cobras.SplitCommand(dashboard.NewCmdDashboard()),
 		cobras.SplitCommand(namespace.NewCmdNamespace()),
+		cobras.SplitCommand(plugin.NewCmdPlugin()),
 		cobras.SplitCommand(ui.NewCmdUI()),
 		cobras.SplitCommand(upgrade.NewCmdUpgrade()),
 		cobras.SplitCommand(version.NewCmdVersion()),
+		cobras.SplitCommand(which.NewCmdWhich(o.resolveLocalPlugin)),
 	}
 
 	// aliases to classic jx commands...
@@ -157,45 +233,264 @@ func Main(args []string) *cobra.Command {
 	filters := []string{"options"}
 
 	templates.ActsAsRootCommand(cmd, filters, getPluginCommandGroups, groups...)
-	handleCommand(po, cmd, args, getPluginCommandGroups)
 	return cmd
 }
 
-func handleCommand(po *templates.Options, cmd *cobra.Command, args []string, getPluginCommandGroups func() (templates.PluginCommandGroups, bool)) {
-	managedPlugins := &managedPluginHandler{
-		JXClient:  po.JXClient,
-		Namespace: po.Namespace,
+func (o *RootOptions) defaults() {
+	if o.In == nil {
+		o.In = os.Stdin
 	}
-	localPlugins := &localPluginHandler{}
-
-	if len(args) == 0 {
-		args = os.Args
+	if o.Out == nil {
+		o.Out = os.Stdout
 	}
-	if len(args) > 1 {
-		cmdPathPieces := args[1:]
+	if o.Err == nil {
+		o.Err = os.Stderr
+	}
+	if o.Environ == nil {
+		o.Environ = os.Environ()
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = httphelpers.GetClient()
+	}
+	if o.Exit == nil {
+		o.Exit = os.Exit
+	}
+}
 
-		pluginDir, err := homedir.DefaultPluginBinDir()
+// Execute runs the root command with the given arguments, which exclude the binary name, dispatching
+// to a plugin if the arguments do not match a built-in command
+func (o *RootOptions) Execute(args []string) error {
+	if o.Cmd == nil {
+		NewCmdRoot(o)
+	}
+	ra, err := parseRootArgs(args)
+	if err == nil {
+		_, err = o.loadConfig()
+	}
+	if err == nil && ra.Output != "" {
+		// lets also pass the output format to any built-in command
+		err = o.Cmd.PersistentFlags().Set(output.FlagName, ra.Output)
+	}
+	if err != nil {
+		log.Logger().Errorf("%v", err)
+		return err
+	}
+	args = ra.Args
+	versions := ra.Versions
+	if len(versions) > 0 {
+		// lets always use a plugin if a specific version is requested
+		found, err := o.dispatchPlugin(args, versions)
+		if err == nil && !found {
+			err = errors.Errorf("no plugin found for jx %s", strings.Join(args, " "))
+		}
 		if err != nil {
 			log.Logger().Errorf("%v", err)
-			os.Exit(1)
 		}
-
+		return err
+	}
+	if len(args) > 0 && !isCobraCommand(args[0]) {
 		// only look for suitable executables if
 		// the specified command does not already exist
-		if _, _, err := cmd.Find(cmdPathPieces); err != nil {
-			if _, managedPluginsEnabled := getPluginCommandGroups(); managedPluginsEnabled {
-				if err := handleEndpointExtensions(managedPlugins, cmdPathPieces, pluginDir); err != nil {
-					log.Logger().Errorf("%v", err)
-					os.Exit(1)
-				}
-			} else {
-				if err := handleEndpointExtensions(localPlugins, cmdPathPieces, pluginDir); err != nil {
-					log.Logger().Errorf("%v", err)
-					os.Exit(1)
-				}
+		if _, _, err := o.Cmd.Find(args); err != nil {
+			found, err := o.dispatchPlugin(args, nil)
+			if err != nil {
+				log.Logger().Errorf("%v", err)
+				return err
+			}
+			if found {
+				return nil
+			}
+			if !strings.HasPrefix(args[0], "-") {
+				return o.unknownCommand(args)
 			}
 		}
 	}
+	return o.executeBuiltIn(args)
+}
+
+// isCobraCommand returns true if the argument is a command which cobra adds when executing such as help or shell completion
+func isCobraCommand(arg string) bool {
+	return arg == "help" || arg == cobra.ShellCompRequestCmd || arg == cobra.ShellCompNoDescRequestCmd
+}
+
+// executeBuiltIn executes a built-in command running any hooks for it
+func (o *RootOptions) executeBuiltIn(args []string) error {
+	o.Cmd.SetArgs(args)
+	c, remaining, err := o.Cmd.Find(args)
+	if err != nil || c == o.Cmd {
+		return o.Cmd.Execute()
+	}
+	commandPath := append(strings.Fields(strings.TrimPrefix(c.CommandPath(), o.Cmd.Name())), hooks.CommandPath(remaining)...)
+	if !o.hooks.HasHooks(hooks.PhasePre, commandPath) && !o.hooks.HasHooks(hooks.PhasePost, commandPath) {
+		return o.Cmd.Execute()
+	}
+
+	err = o.hooks.Pre(commandPath, args)
+	if err != nil {
+		log.Logger().Errorf("%v", err)
+		return err
+	}
+	start := time.Now()
+
+	// lets run the post hooks if the command fails via helper.CheckErr
+	helper.BehaviorOnFatal(func(msg string, code int) {
+		if msg != "" {
+			fmt.Fprintln(o.Err, strings.TrimSuffix(msg, "\n"))
+		}
+		o.hooks.Post(commandPath, args, code, time.Since(start))
+		o.Exit(code)
+	})
+	defer helper.DefaultBehaviorOnFatal()
+
+	err = o.Cmd.Execute()
+	o.hooks.Post(commandPath, args, exitCode(err), time.Since(start))
+	return err
+}
+
+// dispatchPlugin resolves and executes the plugin for the given arguments and plugin versions returning false if there is no plugin
+func (o *RootOptions) dispatchPlugin(args []string, versions map[string]string) (bool, error) {
+	pluginBinDir, err := o.pluginBinDir()
+	if err != nil {
+		return false, err
+	}
+	cfg, err := o.loadConfig()
+	if err != nil {
+		return false, err
+	}
+	auditLog, err := audit.LoadLog(cfg)
+	if err != nil {
+		return false, err
+	}
+	format, err := output.ParseFormat(o.Output)
+	if err != nil {
+		return false, err
+	}
+	environ := o.Environ
+	outputFile := ""
+	if format != nil {
+		// lets ask the plugin to write structured results to a file for us to render
+		tmpDir, err := ioutil.TempDir("", "jx-output-")
+		if err != nil {
+			return false, errors.Wrap(err, "failed to create temp dir")
+		}
+		defer os.RemoveAll(tmpDir)
+		outputFile = filepath.Join(tmpDir, "results.jsonl")
+		environ = append(append([]string{}, environ...), output.EnvOutputFile+"="+outputFile)
+	}
+
+	// lets run the plugin as a child process if we need to audit it, run post hooks or render its output
+	supervised := auditLog != nil || format != nil || o.hooks.HasHooks(hooks.PhasePost, hooks.CommandPath(args))
+	pluginHandler := o.pluginHandler(args, supervised)
+	found, err := handleEndpointExtensions(pluginHandler, args, &extensionOptions{
+		Versions:     versions,
+		PluginBinDir: pluginBinDir,
+		Environ:      environ,
+		AuditLog:     auditLog,
+		Hooks:        o.hooks,
+	})
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		o.Exit(exitErr.ExitCode())
+		return true, nil
+	}
+	if err != nil || !found || format == nil {
+		return found, err
+	}
+
+	results, err := output.LoadResults(outputFile)
+	if err != nil {
+		return true, err
+	}
+	if len(results) == 0 {
+		log.Logger().Warnf("the plugin for jx %s does not support structured output", strings.Join(hooks.CommandPath(args), " "))
+		return true, nil
+	}
+	return true, output.Render(o.Out, format, results)
+}
+
+// loadConfig lazily loads the jx config
+func (o *RootOptions) loadConfig() (*config.Config, error) {
+	if o.config == nil {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load jx config")
+		}
+		o.config = cfg
+		o.hooks = &hooks.Runner{
+			Hooks:   cfg.Hooks,
+			Environ: o.Environ,
+			In:      o.In,
+			Out:     o.Out,
+			Err:     o.Err,
+		}
+	}
+	return o.config, nil
+}
+
+// Resolve resolves and installs the plugin for the given arguments without executing it. Returns nil if there is no plugin
+func (o *RootOptions) Resolve(args []string) (*plugins.Resolution, error) {
+	if o.Cmd == nil {
+		NewCmdRoot(o)
+	}
+	ra, err := parseRootArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	pluginBinDir, err := o.pluginBinDir()
+	if err != nil {
+		return nil, err
+	}
+	return resolvePlugin(o.pluginHandler(ra.Args, false), ra.Args, ra.Versions, pluginBinDir, true)
+}
+
+func (o *RootOptions) pluginHandler(args []string, supervised bool) PluginHandler {
+	if o.PluginHandler != nil {
+		return o.PluginHandler
+	}
+	batchMode := common.IsBatchMode(args, o.Environ)
+	hostEnabled := host.Enabled(o.Environ)
+	localPlugins := &localPluginHandler{
+		BatchMode:  batchMode,
+		HTTPClient: o.HTTPClient,
+		In:         o.In,
+		Out:        o.Out,
+		Err:        o.Err,
+		// lets run plugins as a child process if we offer the host protocol or the standard streams are not the process ones
+		Supervised: supervised || hostEnabled || o.In != os.Stdin || o.Out != os.Stdout || o.Err != os.Stderr,
+	}
+	if hostEnabled {
+		localPlugins.Host = &host.Server{
+			Version:   version.GetVersion(),
+			BatchMode: batchMode,
+		}
+	}
+	if _, managedPluginsEnabled := o.getPluginCommandGroups(); managedPluginsEnabled {
+		return &managedPluginHandler{
+			JXClient:           o.po.JXClient,
+			Namespace:          o.po.Namespace,
+			localPluginHandler: *localPlugins,
+		}
+	}
+	return localPlugins
+}
+
+func (o *RootOptions) pluginBinDir() (string, error) {
+	if o.PluginBinDir != "" {
+		return o.PluginBinDir, nil
+	}
+	return homedir.DefaultPluginBinDir()
+}
+
+// resolveLocalPlugin resolves the plugin for the given arguments without installing it
+func (o *RootOptions) resolveLocalPlugin(args []string) (*plugins.Resolution, error) {
+	pluginBinDir, err := o.pluginBinDir()
+	if err != nil {
+		return nil, err
+	}
+	ra, err := parseRootArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return resolvePlugin(&localPluginHandler{}, ra.Args, ra.Versions, pluginBinDir, false)
 }
 
 func aliasCommand(rootCmd *cobra.Command, fn func(cmd *cobra.Command, args []string), name string, args []string, aliases ...string) *cobra.Command {
@@ -244,10 +539,13 @@ func (h *managedPluginHandler) Lookup(filename, pluginBinDir string) (string, er
 	return h.localPluginHandler.Lookup(filename, pluginBinDir)
 }
 
-func findStandardPlugin(name string) (*v1.Plugin, error) {
+func (h *localPluginHandler) findStandardPlugin(name string) (*v1.Plugin, error) {
 	u := "https://api.github.com/repos/jenkins-x-plugins/" + name + "/releases/latest"
 
-	client := httphelpers.GetClient()
+	client := h.HTTPClient
+	if client == nil {
+		client = httphelpers.GetClient()
+	}
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create http request for %s", u)
@@ -260,6 +558,12 @@ func findStandardPlugin(name string) (*v1.Plugin, error) {
 		return nil, errors.Wrapf(err, "failed to GET endpoint %s", u)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("failed to GET endpoint %s with status %s", u, resp.Status)
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to read response from %s", u)
@@ -279,6 +583,30 @@ func findStandardPlugin(name string) (*v1.Plugin, error) {
 	return &plugin, nil
 }
 
+// findCommunityPlugin finds the community plugin of the given name using a cache of plugins which do not exist
+// to avoid repeated remote lookups
+func (h *localPluginHandler) findCommunityPlugin(name string) (*v1.Plugin, error) {
+	cache, err := plugins.LoadNotFoundCache()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if cache.IsNotFound(name, now) {
+		log.Logger().Debugf("community plugin %s was recently not found", name)
+		return nil, nil
+	}
+	plugin, err := h.findStandardPlugin(name)
+	if err != nil || plugin != nil {
+		return plugin, err
+	}
+	cache.Add(name, now)
+	err = cache.Save()
+	if err != nil {
+		log.Logger().Debugf("failed to save the plugin not found cache: %s", err.Error())
+	}
+	return nil, nil
+}
+
 type githubRelease struct {
 	TagName string `json:"tag_name"`
 }
@@ -288,7 +616,21 @@ func (h *managedPluginHandler) Execute(executablePath string, cmdArgs, environme
 	return h.localPluginHandler.Execute(executablePath, cmdArgs, environment)
 }
 
-type localPluginHandler struct{}
+type localPluginHandler struct {
+	// BatchMode disables prompting to trust community plugins
+	BatchMode bool
+	// Input used to confirm trust of community plugins
+	Input input.Interface
+	// HTTPClient the client used to find community plugins
+	HTTPClient *http.Client
+	// Supervised runs plugins as a child process using the given streams rather than replacing the current process
+	Supervised bool
+	// Host serves the host protocol to supervised plugins if not nil
+	Host *host.Server
+	In   io.Reader
+	Out  io.Writer
+	Err  io.Writer
+}
 
 // Lookup implements PluginHandler
 func (h *localPluginHandler) Lookup(filename, pluginBinDir string) (string, error) {
@@ -298,14 +640,38 @@ func (h *localPluginHandler) Lookup(filename, pluginBinDir string) (string, erro
 		if plugin == nil {
 			// lets see if the plugin is a community plugin...
 			var err2 error
-			plugin, err2 = findStandardPlugin(filename)
+			plugin, err2 = h.findCommunityPlugin(filename)
 			if err2 != nil {
 				return "", errors.Wrapf(err2, "failed to load plugin %s", filename)
 			}
+			if plugin != nil {
+				err2 = verifyPluginPolicy(plugin)
+				if err2 != nil {
+					return "", err2
+				}
+				err2 = h.verifyTrusted(plugin)
+				if err2 != nil {
+					return "", err2
+				}
+			}
+		}
+		if plugin == nil {
+			return "", err
+		}
+		path, err = plugins.EnsurePluginInstalled(*plugin, pluginBinDir)
+		if err != nil || plugins.PluginMap[filename] != nil {
+			return path, err
 		}
-		if plugin != nil {
-			return extensions.EnsurePluginInstalled(*plugin, pluginBinDir)
+		// lets record when the community plugin was last refreshed so it is reused without remote lookups
+		now := time.Now()
+		err = os.Chtimes(path, now, now)
+		if err != nil {
+			log.Logger().Debugf("failed to touch %s: %s", path, err.Error())
 		}
+		return path, nil
+	}
+	err = verifyLocalPluginPolicy(filename, path)
+	if err != nil {
 		return "", err
 	}
 	return path, nil
@@ -314,82 +680,110 @@ func (h *localPluginHandler) Lookup(filename, pluginBinDir string) (string, erro
 // Execute implements PluginHandler
 func (h *localPluginHandler) Execute(executablePath string, cmdArgs, environment []string) error {
 	// Windows does not support exec syscall.
-	if runtime.GOOS == "windows" {
-		cmd := exec.Command(executablePath, cmdArgs...)
+	if !h.Supervised && runtime.GOOS != "windows" {
+		// invoke cmd binary relaying the environment and args given
+		// append executablePath to cmdArgs, as execve will make first argument the "binary name".
+		return syscall.Exec(executablePath, append([]string{executablePath}, cmdArgs...), environment)
+	}
+
+	cmd := exec.Command(executablePath, cmdArgs...)
+	cmd.Stdin = h.In
+	cmd.Stdout = h.Out
+	cmd.Stderr = h.Err
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
+	if cmd.Stdout == nil {
 		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
 		cmd.Stderr = os.Stderr
-		cmd.Stdin = os.Stdin
-		cmd.Env = environment
-		err := cmd.Run()
-		if err == nil {
-			os.Exit(0)
-		}
-		return err
 	}
-
-	// invoke cmd binary relaying the environment and args given
-	// append executablePath to cmdArgs, as execve will make first argument the "binary name".
-	return syscall.Exec(executablePath, append([]string{executablePath}, cmdArgs...), environment)
-}
-
-func handleEndpointExtensions(pluginHandler PluginHandler, cmdArgs []string, pluginBinDir string) error {
-	var remainingArgs []string // all "non-flag" arguments
-
-	for idx := range cmdArgs {
-		if strings.HasPrefix(cmdArgs[idx], "-") {
-			break
+	cmd.Env = environment
+	if h.Host != nil && host.Supported() {
+		stop, err := h.Host.Attach(cmd)
+		if err != nil {
+			return errors.Wrap(err, "failed to offer the host protocol to the plugin")
 		}
-		remainingArgs = append(remainingArgs, strings.Replace(cmdArgs[idx], "-", "_", -1))
+		defer stop()
 	}
 
-	foundBinaryPath := ""
-
-	// attempt to find binary, starting at longest possible name with given cmdArgs
-	for len(remainingArgs) > 0 {
-		commandName := fmt.Sprintf("jx-%s", strings.Join(remainingArgs, "-"))
-
-		// lets try the correct plugin versions first
-		path := ""
-		var err error
-		for i := range plugins.Plugins {
-			p := plugins.Plugins[i]
-			if p.Spec.Name == commandName {
-				path, err = extensions.EnsurePluginInstalled(p, pluginBinDir)
-				if err != nil {
-					return errors.Wrapf(err, "failed to install binary plugin %s version %s to %s", commandName, p.Spec.Version, pluginBinDir)
+	// lets leave handling interrupts to the plugin which gets them from the terminal and forward any
+	// termination signals so that the plugin is not orphaned
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	err := cmd.Start()
+	if err != nil {
+		return errors.Wrapf(err, "failed to start plugin %s", executablePath)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == os.Interrupt {
+					continue
 				}
-				if path != "" {
-					break
+				err := cmd.Process.Signal(sig)
+				if err != nil {
+					log.Logger().Debugf("failed to forward signal %s to plugin %s: %s", sig, executablePath, err.Error())
 				}
+			case <-done:
+				return
 			}
 		}
+	}()
+	return cmd.Wait()
+}
 
-		// lets see if there's a local build of the plugin on the PATH for developers...
-		localPath, err := pluginHandler.Lookup(commandName, pluginBinDir)
-		if err == nil && localPath != "" {
-			path = localPath
-		}
-		if path != "" {
-			foundBinaryPath = path
-			break
-		}
-		remainingArgs = remainingArgs[:len(remainingArgs)-1]
+// extensionOptions the options for resolving and executing a plugin
+type extensionOptions struct {
+	// Versions any specific plugin versions requested indexed by plugin name
+	Versions map[string]string
+	// PluginBinDir the directory plugins are installed into
+	PluginBinDir string
+	// Environ the environment passed to the plugin
+	Environ []string
+	// AuditLog the audit log to record the plugin invocation in if not nil
+	AuditLog *audit.Log
+	// Hooks the hooks to run before and after the plugin
+	Hooks *hooks.Runner
+}
+
+// handleEndpointExtensions resolves and executes the plugin for the given arguments returning false if there is no plugin
+func handleEndpointExtensions(pluginHandler PluginHandler, cmdArgs []string, o *extensionOptions) (bool, error) {
+	r, err := resolvePlugin(pluginHandler, cmdArgs, o.Versions, o.PluginBinDir, true)
+	if err != nil {
+		return false, err
+	}
+	if r == nil {
+		return false, nil
 	}
+	logResolution(r)
 
-	if foundBinaryPath == "" {
-		return nil
+	commandPath := hooks.CommandPath(cmdArgs)
+	start := time.Now()
+	err = o.Hooks.Pre(commandPath, cmdArgs)
+	if err != nil {
+		if o.AuditLog != nil {
+			recordAudit(o.AuditLog, r, cmdArgs, start, err)
+		}
+		return true, err
 	}
 
-	nextArgs := cmdArgs[len(remainingArgs):]
-	log.Logger().Debugf("using the plugin command: %s", termcolor.ColorInfo(foundBinaryPath+" "+strings.Join(nextArgs, " ")))
+	log.Logger().Debugf("using the plugin command: %s", termcolor.ColorInfo(r.Path+" "+strings.Join(r.Args, " ")))
 
-	// invoke cmd binary relaying the current environment and args given
-	// remainingArgs will always have at least one element.
-	// execute will make remainingArgs[0] the "binary name".
-	if err := pluginHandler.Execute(foundBinaryPath, nextArgs, os.Environ()); err != nil {
-		return err
+	// invoke cmd binary relaying the environment and args given
+	// execute will make the plugin path the "binary name".
+	start = time.Now()
+	err = pluginHandler.Execute(r.Path, r.Args, o.Environ)
+	if o.AuditLog != nil {
+		recordAudit(o.AuditLog, r, cmdArgs, start, err)
 	}
-	return nil
+	o.Hooks.Post(commandPath, cmdArgs, exitCode(err), time.Since(start))
+	return true, err
 }
 
 // FindPluginBinary tries to find the jx-foo binary plugin in the plugins dir `~/.jx/plugins/jx/bin` dir `
@@ -0,0 +1,281 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/audit"
+	"github.com/jenkins-x/jx/pkg/cmd"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePluginHandler struct {
+	plugins  map[string]string
+	executed []string
+	environ  []string
+}
+
+func (h *fakePluginHandler) Lookup(filename, _ string) (string, error) {
+	path := h.plugins[filename]
+	if path == "" {
+		return "", errors.Errorf("plugin %s not found", filename)
+	}
+	return path, nil
+}
+
+func (h *fakePluginHandler) Execute(executablePath string, cmdArgs, environment []string) error {
+	h.executed = append([]string{executablePath}, cmdArgs...)
+	h.environ = environment
+	return nil
+}
+
+func TestRootCommandPluginDispatch(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-root-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", tmpDir)
+	defer os.Unsetenv("JX3_HOME")
+
+	handler := &fakePluginHandler{
+		plugins: map[string]string{"jx-mytest": "/fake/jx-mytest"},
+	}
+	exitCode := -1
+	o := &cmd.RootOptions{
+		Out:           &bytes.Buffer{},
+		Err:           &bytes.Buffer{},
+		Environ:       []string{"FOO=bar"},
+		PluginHandler: handler,
+		PluginBinDir:  tmpDir,
+		Exit: func(code int) {
+			exitCode = code
+		},
+	}
+
+	// creating the command should not resolve any plugins
+	root := cmd.NewCmdRoot(o)
+	require.NotNil(t, root)
+	assert.Empty(t, handler.executed)
+
+	err = o.Execute([]string{"mytest", "something", "--flag"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/fake/jx-mytest", "something", "--flag"}, handler.executed)
+	assert.Equal(t, []string{"FOO=bar"}, handler.environ)
+	assert.Equal(t, -1, exitCode)
+}
+
+func TestRootCommandBuiltIn(t *testing.T) {
+	handler := &fakePluginHandler{}
+	out := &bytes.Buffer{}
+	o := &cmd.RootOptions{
+		Out:           out,
+		Err:           &bytes.Buffer{},
+		PluginHandler: handler,
+	}
+	err := o.Execute([]string{"--help"})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Jenkins X")
+	assert.Empty(t, handler.executed)
+}
+
+func TestRootCommandBuiltInOutput(t *testing.T) {
+	out := &bytes.Buffer{}
+	o := &cmd.RootOptions{
+		Out:           out,
+		Err:           &bytes.Buffer{},
+		PluginHandler: &fakePluginHandler{},
+	}
+	err := o.Execute([]string{"--output", "jsonpath={[*].version}", "version"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, strings.TrimSpace(out.String()), "the version should be rendered to the root output")
+}
+
+func TestRootCommandPluginExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-root-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", tmpDir)
+	defer os.Unsetenv("JX3_HOME")
+
+	script := filepath.Join(tmpDir, "jx-exitcode")
+	err = ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"hello $1\"\nexit 3\n"), 0755)
+	require.NoError(t, err)
+	cfg := &config.Config{PluginLinks: map[string]string{"jx-exitcode": script}}
+	require.NoError(t, cfg.Save())
+
+	out := &bytes.Buffer{}
+	exitCode := -1
+	o := &cmd.RootOptions{
+		Out:          out,
+		Err:          &bytes.Buffer{},
+		PluginBinDir: tmpDir,
+		Exit: func(code int) {
+			exitCode = code
+		},
+	}
+	err = o.Execute([]string{"exitcode", "world"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello world\n", out.String())
+	assert.Equal(t, 3, exitCode)
+}
+
+func TestRootCommandAuditLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-root-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", tmpDir)
+	defer os.Unsetenv("JX3_HOME")
+	auditDir := filepath.Join(tmpDir, "audit")
+	os.Setenv(audit.EnvAuditLog, auditDir)
+	defer os.Unsetenv(audit.EnvAuditLog)
+
+	script := filepath.Join(tmpDir, "jx-audited")
+	err = ioutil.WriteFile(script, []byte("#!/bin/sh\nexit 2\n"), 0755)
+	require.NoError(t, err)
+	cfg := &config.Config{
+		PluginLinks: map[string]string{"jx-audited": script, "jx-denied": script},
+		Hooks: []config.Hook{
+			{
+				Name:     "deny",
+				Commands: []string{"denied"},
+				Pre:      []string{"false"},
+			},
+		},
+	}
+	require.NoError(t, cfg.Save())
+
+	exitCode := -1
+	o := &cmd.RootOptions{
+		Out:          &bytes.Buffer{},
+		Err:          &bytes.Buffer{},
+		PluginBinDir: tmpDir,
+		Exit: func(code int) {
+			exitCode = code
+		},
+	}
+	err = o.Execute([]string{"audited", "something", "--token", "mysecret"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, exitCode)
+
+	entries, err := (&audit.Log{Dir: auditDir}).Query(audit.Query{Plugin: "audited"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, "jx-audited", e.Plugin)
+	assert.Equal(t, 2, e.ExitCode)
+	assert.Equal(t, []string{"audited", "something", "--token", audit.Redacted}, e.Args)
+	assert.NotEmpty(t, e.User)
+	assert.Empty(t, e.Error)
+
+	// invocations denied by a pre hook are recorded too
+	err = o.Execute([]string{"denied"})
+	require.Error(t, err)
+	entries, err = (&audit.Log{Dir: auditDir}).Query(audit.Query{Plugin: "denied"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].ExitCode)
+	assert.Contains(t, entries[0].Error, "pre hook deny failed")
+}
+
+func TestRootCommandPluginOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-root-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", tmpDir)
+	defer os.Unsetenv("JX3_HOME")
+
+	script := filepath.Join(tmpDir, "jx-structured")
+	err = ioutil.WriteFile(script, []byte(`#!/bin/sh
+echo '{"kind":"Thing","items":[{"name":"cheese"}]}' >> "$JX_OUTPUT_FILE"
+`), 0755)
+	require.NoError(t, err)
+	cfg := &config.Config{PluginLinks: map[string]string{"jx-structured": script}}
+	require.NoError(t, cfg.Save())
+
+	out := &bytes.Buffer{}
+	o := &cmd.RootOptions{
+		Out:          out,
+		Err:          &bytes.Buffer{},
+		PluginBinDir: tmpDir,
+	}
+	err = o.Execute([]string{"--output", "jsonpath={[*].name}", "structured"})
+	require.NoError(t, err)
+	assert.Equal(t, "cheese\n", out.String())
+}
+
+func TestRootCommandUnknownCommandSuggestions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-root-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", tmpDir)
+	defer os.Unsetenv("JX3_HOME")
+
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "plugin-policy.yaml"), []byte(`rules:
+- name: no-previews
+  action: deny
+  plugins:
+  - jx-preview
+`), 0600)
+	require.NoError(t, err)
+
+	newOptions := func() (*cmd.RootOptions, *bytes.Buffer) {
+		errOut := &bytes.Buffer{}
+		return &cmd.RootOptions{
+			Out:           &bytes.Buffer{},
+			Err:           errOut,
+			PluginHandler: &fakePluginHandler{},
+			PluginBinDir:  tmpDir,
+		}, errOut
+	}
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"gitop"},
+			expected: "\tgitops\tplugin jx-gitops version " + plugins.PluginMap["jx-gitops"].Spec.Version + " which is not installed yet\n",
+		},
+		{
+			args:     []string{"namespac"},
+			expected: "\tnamespace\t",
+		},
+		{
+			args:     []string{"dahs"},
+			expected: "\tdash\talias for: jx dashboard\n",
+		},
+		{
+			args:     []string{"previw"},
+			expected: "which is blocked by the plugin policies",
+		},
+	}
+	for _, tc := range testCases {
+		o, errOut := newOptions()
+		err = o.Execute(tc.args)
+		require.Error(t, err, "for args %v", tc.args)
+		assert.Contains(t, errOut.String(), "Did you mean this?", "for args %v", tc.args)
+		assert.Contains(t, errOut.String(), tc.expected, "for args %v", tc.args)
+	}
+
+	o, errOut := newOptions()
+	err = o.Execute([]string{"zzzzzzzz"})
+	require.Error(t, err)
+	assert.NotContains(t, errOut.String(), "Did you mean this?")
+}
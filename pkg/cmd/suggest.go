@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/hooks"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxSuggestions the maximum number of suggestions for an unknown command
+	maxSuggestions = 5
+
+	// suggestionDistance the maximum edit distance of a suggestion
+	suggestionDistance = 2
+)
+
+// suggestion a command suggested for an unknown command
+type suggestion struct {
+	// Command the command such as `gitops` or `release notes`
+	Command string
+	// Description describes the command and whether it is installed or allowed
+	Description string
+
+	distance int
+}
+
+// unknownCommand reports an unknown command with any did-you-mean suggestions from the built-in commands and plugins
+func (o *RootOptions) unknownCommand(args []string) error {
+	words := hooks.CommandPath(args)
+	command := strings.Join(words, " ")
+	fmt.Fprintf(o.Err, "Error: unknown command %q for %q\n", command, o.Cmd.Name())
+
+	suggestions, err := o.suggestCommands(words)
+	if err != nil {
+		log.Logger().Debugf("failed to find suggestions: %s", err.Error())
+	}
+	if len(suggestions) > 0 {
+		fmt.Fprintf(o.Err, "\nDid you mean this?\n")
+		for _, s := range suggestions {
+			fmt.Fprintf(o.Err, "\t%s\t%s\n", s.Command, s.Description)
+		}
+	}
+	fmt.Fprintf(o.Err, "\nRun '%s --help' for usage.\n", o.Cmd.CommandPath())
+	return errors.Errorf("unknown command %q for %q", command, o.Cmd.Name())
+}
+
+// suggestCommands returns the closest built-in commands, aliases and plugins to the given command words
+func (o *RootOptions) suggestCommands(words []string) ([]*suggestion, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+	var answer []*suggestion
+	found := map[string]bool{}
+	add := func(command string, describe func() string) {
+		if found[command] {
+			return
+		}
+		typed := words
+		n := len(strings.Fields(command))
+		if n < len(typed) {
+			typed = typed[:n]
+		}
+		text := strings.ToLower(strings.Join(typed, " "))
+		distance := editDistance(text, command)
+		if distance > suggestionDistance && !(len(text) > 1 && strings.HasPrefix(command, text)) {
+			return
+		}
+		found[command] = true
+		answer = append(answer, &suggestion{
+			Command:     command,
+			Description: describe(),
+			distance:    distance,
+		})
+	}
+
+	for _, c := range o.Cmd.Commands() {
+		c := c
+		if !c.IsAvailableCommand() {
+			continue
+		}
+		add(c.Name(), func() string {
+			return c.Short
+		})
+		for _, alias := range c.Aliases {
+			add(alias, func() string {
+				return "alias for: jx " + c.Name()
+			})
+		}
+	}
+
+	err := o.suggestPlugins(add)
+	sort.SliceStable(answer, func(i, j int) bool {
+		if answer[i].distance != answer[j].distance {
+			return answer[i].distance < answer[j].distance
+		}
+		return answer[i].Command < answer[j].Command
+	})
+	if len(answer) > maxSuggestions {
+		answer = answer[:maxSuggestions]
+	}
+	return answer, err
+}
+
+// suggestPlugins adds the linked, catalog, project, installed and trusted plugins as suggestions.
+// There is no synced plugin index, plugin subcommand manifest or user alias in jx yet so they are not suggested
+func (o *RootOptions) suggestPlugins(add func(command string, describe func() string)) error {
+	cfg, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	pluginBinDir, err := o.pluginBinDir()
+	if err != nil {
+		return err
+	}
+	command := func(name string) string {
+		return strings.Replace(strings.TrimPrefix(name, "jx-"), "-", " ", -1)
+	}
+	localPlugin := func(kind, name, path string) func() string {
+		return func() string {
+			description := fmt.Sprintf("%s plugin %s", kind, name)
+			if err := verifyLocalPluginPolicy(name, path); err != nil {
+				description += " which is blocked by the plugin policies"
+			}
+			return description
+		}
+	}
+
+	var linked []string
+	for name := range cfg.PluginLinks {
+		linked = append(linked, name)
+	}
+	sort.Strings(linked)
+	for _, name := range linked {
+		add(command(name), localPlugin("linked", name, cfg.PluginLinks[name]))
+	}
+
+	catalog, err := plugins.CatalogPlugins(cfg)
+	if err != nil {
+		return err
+	}
+	for i := range catalog {
+		p := &catalog[i]
+		add(command(p.Spec.Name), func() string {
+			return describeCatalogPlugin(p, pluginBinDir)
+		})
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the current working directory")
+	}
+	projectPluginDir, err := plugins.FindProjectPluginDir(wd)
+	if err != nil {
+		return err
+	}
+	if projectPluginDir != "" {
+		paths, err := plugins.ProjectPlugins(projectPluginDir)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			name := plugins.ProjectPluginName(path)
+			add(command(name), localPlugin("project", name, path))
+		}
+	}
+
+	installed, err := plugins.InstalledPlugins(pluginBinDir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(pluginBinDir, fmt.Sprintf("%s-%s", name, installed[name]))
+		add(command(name), localPlugin("installed", name, path))
+	}
+
+	trustStore, err := plugins.LoadTrustStore()
+	if err != nil {
+		return err
+	}
+	for _, p := range trustStore.Plugins {
+		p := p
+		add(command(p.Name), func() string {
+			return fmt.Sprintf("trusted community plugin %s from %s which is not installed yet", p.Name, p.Repository)
+		})
+	}
+	return nil
+}
+
+// describeCatalogPlugin describes a catalog plugin and whether it is installed or blocked
+func describeCatalogPlugin(p *jenkinsv1.Plugin, pluginBinDir string) string {
+	description := fmt.Sprintf("plugin %s version %s", p.Spec.Name, p.Spec.Version)
+	if err := verifyPluginPolicy(p); err != nil {
+		return description + " which is blocked by the plugin policies"
+	}
+	path := filepath.Join(pluginBinDir, fmt.Sprintf("%s-%s", p.Spec.Name, p.Spec.Version))
+	if exists, err := files.FileExists(path); err == nil && !exists {
+		return description + " which is not installed yet"
+	}
+	return description
+}
+
+// editDistance returns the Levenshtein distance between the two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -2,6 +2,8 @@ package upgrade
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,12 +19,14 @@ import (
 	"github.com/jenkins-x/jx-api/v4/pkg/util"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
 
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/output"
 	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/jenkins-x/jx/pkg/versionstreams"
 
 	jxcore "github.com/jenkins-x/jx-api/v4/pkg/apis/core/v4beta1"
 	"github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
-	"github.com/rhysd/go-github-selfupdate/selfupdate"
 
 	"github.com/blang/semver"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/versionstream"
@@ -32,6 +36,7 @@ import (
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -45,23 +50,73 @@ var (
 	cmdCLIExample = templates.Examples(`
 		# upgrades your jx CLI
 		jx upgrade cli
-	`)
-)
 
-const (
-	// BinaryDownloadBaseURL the base URL for downloading the binary from - will always have "VERSION/jx-OS-ARCH.EXTENSION" appended to it when used
-	BinaryDownloadBaseURL  = "https://github.com/jenkins-x/jx/releases/download/v"
-	LatestVersionstreamURL = "https://github.com/jenkins-x/jxr-versions.git"
+		# view how the version to upgrade to is resolved without changing anything
+		jx upgrade cli --dry-run
+
+		# match the version of jx in the version stream of the cluster even if it is older
+		jx upgrade cli --from-environment
+
+		# switch to the beta channel which upgrades to the latest pre-release
+		jx upgrade cli --channel beta
+
+		# view the resolution report as JSON
+		jx upgrade cli --dry-run --output json
+
+		# view the upgrade history and the versions available to rollback to
+		jx upgrade cli --history
+
+		# restore the previous version after a bad upgrade
+		jx upgrade cli --rollback
+
+		# restore a specific previous version
+		jx upgrade cli --rollback --to 3.2.10
+
+		# upgrade without prompting after showing the release notes
+		jx upgrade cli --batch-mode
+
+		# upgrade from a mirror of the jx releases
+		jx upgrade cli --release-base-url https://mirror.example.com/jx/{{.Version}} --asset-template "jx-{{.OS}}-{{.Arch}}.{{.Extension}}"
+	`)
 )
 
 // UpgradeOptions the options for upgrading a cluster
 type CLIOptions struct {
-	CommandRunner       cmdrunner.CommandRunner
-	GitClient           gitclient.Interface
-	JXClient            versioned.Interface
-	Version             string
-	VersionStreamGitURL string
-	FromEnvironment     bool
+	CommandRunner           cmdrunner.CommandRunner
+	GitClient               gitclient.Interface
+	JXClient                versioned.Interface
+	Version                 string
+	VersionStreamGitURL     string
+	VersionStreamRef        string
+	FromEnvironment         bool
+	Channel                 string
+	AllowDowngrade          bool
+	DryRun                  bool
+	Rollback                bool
+	RollbackTo              string
+	History                 bool
+	KeepBackups             int
+	SkipVerify              bool
+	IgnorePackageManager    bool
+	PublicKey               string
+	Executable              string
+	ReleaseBaseURL          string
+	AssetTemplate           string
+	DefaultVersionStreamURL string
+	ReleaseFeedURL          string
+	BatchMode               bool
+	Input                   input.Interface
+	Out                     io.Writer
+	Output                  *output.Format
+
+	// assetURLs the download URLs of versions published in a release feed
+	assetURLs map[string]string
+
+	// installation how jx was installed if it is to be upgraded
+	installation *Installation
+
+	// release where the jx releases are downloaded from
+	release *ReleaseSource
 }
 
 // NewCmdUpgrade creates a command object for the command
@@ -74,71 +129,196 @@ func NewCmdUpgradeCLI() (*cobra.Command, *CLIOptions) {
 		Long:    cmdCLILong,
 		Example: cmdCLIExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := o.Run()
+			var err error
+			o.Output, err = output.FormatFromCommand(cmd)
+			helper.CheckErr(err)
+			if o.Out == nil {
+				o.Out = cmd.OutOrStdout()
+			}
+			err = o.Run()
 			helper.CheckErr(err)
 		},
 	}
-	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "The specific version to upgrade to (requires --brew=false on macOS)")
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "The specific version to upgrade to. Package managers such as brew cannot install a specific version so it may require --ignore-package-manager")
 	cmd.Flags().StringVarP(&o.VersionStreamGitURL, "version-stream-git-url", "", "", "The version stream git URL to lookup the jx cli version to upgrade to")
+	cmd.Flags().StringVarP(&o.VersionStreamRef, "version-stream-ref", "", "", "The git ref of the version stream to use. Defaults to the locked commit or ref in the local versionStream/Kptfile or the default branch")
 	cmd.Flags().BoolVarP(&o.FromEnvironment, "from-environment", "e", false, "Use the clusters dev environment to obtain the version stream URL to find correct version to upgrade the jx cli, this overrides version-stream-git-url")
+	cmd.Flags().BoolVarP(&o.AllowDowngrade, "allow-downgrade", "", false, "Installs the resolved version even if it is older than the current version. Enabled by --from-environment so that the CLI matches the cluster")
+	cmd.Flags().StringVarP(&o.Channel, "channel", "", "", "The release channel to upgrade from which is remembered for future upgrades: "+strings.Join(Channels, ", ")+". Stable uses the version stream, beta the latest pre-release from the release feed and nightly the configured nightly feed")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "Reports how the version to upgrade to is resolved without changing anything")
+	cmd.Flags().BoolVarP(&o.Rollback, "rollback", "", false, "Restores the previous version of the jx binary kept by an earlier upgrade")
+	cmd.Flags().StringVarP(&o.RollbackTo, "to", "", "", "The previous version to restore when using --rollback")
+	cmd.Flags().BoolVarP(&o.History, "history", "", false, "Shows the history of upgrades and rollbacks and the versions available to rollback to")
+	cmd.Flags().IntVarP(&o.KeepBackups, "keep", "", DefaultKeepBackups, "The number of previous versions of the jx binary to keep for rollbacks")
+	cmd.Flags().BoolVarP(&o.IgnorePackageManager, "ignore-package-manager", "", false, "Replaces the jx binary directly even if it was installed via a package manager such as brew, asdf, dpkg or rpm")
+	cmd.Flags().BoolVarP(&o.SkipVerify, "skip-verify", "", false, "Skips verifying the downloaded release against its published checksums")
+	cmd.Flags().StringVarP(&o.PublicKey, "public-key", "", "", "The PEM encoded public key file used to verify the signature of the release checksums. When set the signature must be published")
+	cmd.Flags().StringVarP(&o.ReleaseBaseURL, "release-base-url", "", "", "The template of the URL the jx releases are downloaded from such as a mirror or fork. Can also be set via $"+EnvReleaseBaseURL+" or upgrade.releaseBaseURL in the jx config file. Defaults to "+DefaultReleaseBaseURL)
+	cmd.Flags().StringVarP(&o.AssetTemplate, "asset-template", "", "", "The template of the release archive names using {{.OS}}, {{.Arch}}, {{.Version}} and {{.Extension}}. Can also be set via $"+EnvReleaseAssetTemplate+" or upgrade.assetTemplate in the jx config file. Defaults to "+DefaultAssetTemplate)
+	cmd.Flags().StringVarP(&o.ReleaseFeedURL, "release-feed-url", "", "", "The release feed in the format of the GitHub releases API or a local file in the same format used by the beta channel and to show the release notes. Can also be set via $"+EnvReleaseFeedURL+" or upgrade.releaseFeedURL in the jx config file. Defaults to "+DefaultReleaseFeedURL)
+	cmd.Flags().BoolVarP(&o.BatchMode, "batch-mode", "b", false, "Upgrades without prompting to confirm after showing the release notes")
+	cmd.Flags().StringVarP(&o.DefaultVersionStreamURL, "default-version-stream-git-url", "", "", "The version stream git URL used when there is no local Kptfile or dev environment. Can also be set via $"+EnvVersionStreamURL+" or upgrade.versionStreamURL in the jx config file. Defaults to "+LatestVersionstreamURL)
 	return cmd, o
 }
 
 // Run implements the command
 func (o *CLIOptions) Run() error {
-	var err error
-	o.JXClient, err = jxclient.LazyCreateJXClient(o.JXClient)
+	if o.RollbackTo != "" && !o.Rollback {
+		return errors.Errorf("the --to flag requires --rollback")
+	}
+	if o.History {
+		return o.printHistory()
+	}
+	if o.Rollback {
+		return o.RollbackJx()
+	}
+
+	report, err := o.Resolve()
 	if err != nil {
-		return errors.Wrapf(err, "failed to create jx client")
+		return err
 	}
+	if o.DryRun || o.Output != nil {
+		err = o.printReport(report)
+		if err != nil {
+			return err
+		}
+	}
+	if o.DryRun || (report.Decision != DecisionUpgrade && report.Decision != DecisionDowngrade) {
+		return nil
+	}
+	if report.Decision == DecisionUpgrade {
+		confirmed, err := o.confirmUpgrade(report)
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+	if report.Decision == DecisionDowngrade {
+		log.Logger().Warnf("downgrading jx from %s to %s", report.CurrentVersion, termcolor.ColorWarning(report.Version))
+	}
+	if o.installation != nil && o.installation.Method != InstallMethodBinary {
+		return o.upgradeViaPackageManager(o.installation)
+	}
+	return o.InstallJx(true, report.Version)
+}
+
+// Resolve resolves the version of jx to upgrade to and whether to install it without changing anything
+func (o *CLIOptions) Resolve() (*Report, error) {
+	channel, cfg, err := o.resolveChannel()
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Channel: channel}
 
 	// upgrading to a specific version is not yet supported in brew so lets disable it for upgrades
-	candidateInstallVersion, err := o.candidateInstallVersion()
+	candidateInstallVersion, err := o.candidateInstallVersion(channel, cfg, report)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find jx cli version")
+	}
+	err = o.saveChannel(cfg)
+	if err != nil {
+		return nil, err
+	}
+	report.Version = candidateInstallVersion.String()
+	report.DownloadURL, err = o.DownloadURL(report.Version)
 	if err != nil {
-		return errors.Wrapf(err, "failed to find jx cli version")
+		return nil, err
 	}
 
 	currentVersion, err := version.GetSemverVersion()
 	if err != nil {
-		return errors.Wrap(err, "failed to determine version of currently install jx release")
+		return nil, errors.Wrap(err, "failed to determine version of currently install jx release")
 	}
+	report.CurrentVersion = currentVersion.String()
 
 	log.Logger().Debugf("Current version of jx: %s", termcolor.ColorInfo(currentVersion))
 
-	if o.needsUpgrade(currentVersion, candidateInstallVersion) {
-		shouldUpgrade, err := o.ShouldUpdate(candidateInstallVersion)
-		if err != nil {
-			return errors.Wrap(err, "failed to determine if we should upgrade")
+	report.Decision = DecisionNoOp
+	switch {
+	case !o.needsUpgrade(currentVersion, candidateInstallVersion):
+		report.Reason = "already on the resolved version"
+	case candidateInstallVersion.LT(currentVersion):
+		switch {
+		case !o.AllowDowngrade && !o.FromEnvironment:
+			report.Reason = "the resolved version is older than the current version. Use --allow-downgrade to install it"
+		case hasPreRelease(currentVersion, "dev"):
+			report.Reason = "the current version is a dev build"
+		default:
+			report.Decision = DecisionDowngrade
+			report.Reason = "the resolved version is older than the current version and downgrades are allowed"
+			if o.FromEnvironment && !o.AllowDowngrade {
+				report.Reason += " so that the CLI matches the version stream of the cluster"
+			}
+		}
+	default:
+		// a specific version is installed whatever the channel
+		if report.VersionStreamSource == SourceVersionFlag {
+			channel = ChannelNightly
 		}
-		if shouldUpgrade {
-			return o.InstallJx(true, candidateInstallVersion.String())
+		report.Reason = updateBlocker(channel, currentVersion, candidateInstallVersion)
+		if report.Reason == "" {
+			report.Decision = DecisionUpgrade
+			report.Reason = "the resolved version is newer than the current version"
 		}
 	}
 
-	return nil
+	report.InstallMethod = InstallMethodBinary
+	if report.Decision != DecisionNoOp && !o.IgnorePackageManager {
+		exe := o.Executable
+		if exe == "" {
+			exe, err = os.Executable()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the jx executable which is running this command")
+			}
+		}
+		o.installation, err = o.DetectInstallation(exe, report.Version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to detect how jx was installed")
+		}
+		report.InstallMethod = o.installation.Method
+		report.Instructions = o.installation.Instructions
+	}
+	return report, nil
 }
 
-func (o *CLIOptions) candidateInstallVersion() (semver.Version, error) {
+func (o *CLIOptions) candidateInstallVersion(channel string, cfg *config.Config, report *Report) (semver.Version, error) {
 	var err error
-	if o.Version == "" {
+	if o.Version == "" && channel != ChannelStable {
+		if o.VersionStreamGitURL != "" || o.FromEnvironment {
+			return semver.Version{}, errors.Errorf("the version stream can only be used with the %s channel", ChannelStable)
+		}
+		o.Version, err = o.feedVersion(channel, cfg, report)
+		if err != nil {
+			return semver.Version{}, errors.Wrapf(err, "failed to get jx cli version for the %s channel", channel)
+		}
+	} else if o.Version == "" {
 		// if version stream URL is set via a flag use this
 		gitURL := o.VersionStreamGitURL
 		if gitURL == "" {
 			// get the versionstream URL used to find what jx version to upgrade to
-			gitURL, err = o.getVersionStreamURL(gitURL)
+			gitURL, err = o.getVersionStreamURL(report)
 			if err != nil {
 				return semver.Version{}, errors.Wrapf(err, "failed to get version stream ")
 			}
+		} else {
+			report.VersionStreamSource = SourceFlag
+			report.VersionStreamReason = "the --version-stream-git-url flag was specified"
 		}
 
 		if gitURL == "" {
 			return semver.Version{}, errors.New("no version stream URL to get correct jx version")
 		}
+		report.VersionStreamURL = gitURL
+		if o.VersionStreamRef != "" {
+			report.VersionStreamRef = o.VersionStreamRef
+		}
 
-		o.Version, err = o.getJXVersion(gitURL)
+		o.Version, err = o.getJXVersion(gitURL, report.VersionStreamRef, report.VersionStreamDirectory)
 		if err != nil {
 			return semver.Version{}, errors.Wrapf(err, "failed to get jx cli version from %s", gitURL)
 		}
+	} else {
+		report.VersionStreamSource = SourceVersionFlag
+		report.VersionStreamReason = "the --version flag was specified so no version stream is used"
 	}
 
 	requestedVersion, err := semver.New(o.Version)
@@ -149,15 +329,15 @@ func (o *CLIOptions) candidateInstallVersion() (semver.Version, error) {
 }
 
 // get the versionstream URL used to find what jx version to upgrade to
-func (o *CLIOptions) getVersionStreamURL(gitURL string) (string, error) {
+func (o *CLIOptions) getVersionStreamURL(report *Report) (string, error) {
 	// lookup the version stream URL from the Kptfile
 	// we do this in case we are switching version streams and need to update CLI before running jx gitops upgrade
-
+	gitURL := ""
 	path := filepath.Join("versionStream", "Kptfile")
 	exists, err := files.FileExists(path)
 
 	if o.FromEnvironment && exists {
-		return "", errors.New("local %s found in current directory and from-environment flag set, pick only one")
+		return "", errors.Errorf("local %s found in current directory and from-environment flag set, pick only one", path)
 	}
 
 	// if there's a local kptfile found use the versionstream git details in that, if not use the cluster git repo
@@ -173,27 +353,63 @@ func (o *CLIOptions) getVersionStreamURL(gitURL string) (string, error) {
 				gitURL = strings.TrimSpace(gitURL)
 
 				log.Logger().Infof("using local versionstream URL %s from Kptfile to resolve jx version", gitURL)
+				report.VersionStreamSource = SourceKptfile
+				report.VersionStreamReason = fmt.Sprintf("found the upstream git repository in %s in the current directory", path)
+
+				// prefer the commit the cluster repository is locked to over the ref it tracks
+				report.VersionStreamRef = firstKptfileValue(node,
+					[]string{"upstreamLock", "git", "commit"},
+					[]string{"upstream", "git", "commit"},
+					[]string{"upstream", "git", "ref"})
+				report.VersionStreamDirectory = firstKptfileValue(node, []string{"upstream", "git", "directory"})
 			}
 		}
 	}
 	if o.FromEnvironment {
 		// lookup the cluster git repo from the dev environment and use that as the versionstream
+		o.JXClient, err = jxclient.LazyCreateJXClient(o.JXClient)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to create jx client")
+		}
 		env, err := jxenv.GetDevEnvironment(o.JXClient, jxcore.DefaultNamespace)
 		if err == nil {
 			if env.Spec.Source.URL != "" {
 				gitURL = env.Spec.Source.URL
 				log.Logger().Infof("using clusters dev environent versionstream URL %s from Kptfile to resolve jx version", gitURL)
+				report.VersionStreamSource = SourceEnvironment
+				report.VersionStreamReason = fmt.Sprintf("the --from-environment flag was specified and the dev Environment in namespace %s has a source URL", jxcore.DefaultNamespace)
 			}
 		}
 	}
 	if gitURL == "" {
-		// if none of the options above find a git url lets default to the latest upstream version stream
-		gitURL = LatestVersionstreamURL
-		log.Logger().Infof("using latest upstream versionstream URL %s from Kptfile to resolve jx version", gitURL)
+		// if none of the options above find a git url lets default to the configured or latest upstream version stream
+		source, err := o.releaseSource()
+		if err != nil {
+			return "", err
+		}
+		gitURL = source.VersionStreamURL
+		log.Logger().Infof("using default versionstream URL %s to resolve jx version", gitURL)
+		report.VersionStreamSource = SourceDefault
+		report.VersionStreamReason = "no flag, local Kptfile or dev Environment source URL was found so the default version stream is used"
 	}
 	return gitURL, nil
 }
 
+// firstKptfileValue returns the first non empty value of the paths in the Kptfile
+func firstKptfileValue(node *yaml.RNode, paths ...[]string) string {
+	for _, p := range paths {
+		value, err := node.Pipe(yaml.Lookup(p...))
+		if err != nil {
+			continue
+		}
+		text := strings.TrimSpace(yaml.GetValue(value))
+		if text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
 func (o *CLIOptions) needsUpgrade(currentVersion, latestVersion semver.Version) bool {
 	if latestVersion.EQ(currentVersion) {
 		log.Logger().Infof("You are already on the latest version of jx %s", termcolor.ColorInfo(currentVersion.String()))
@@ -202,27 +418,41 @@ func (o *CLIOptions) needsUpgrade(currentVersion, latestVersion semver.Version)
 	return true
 }
 
-// ShouldUpdate checks if CLI version should be updated
+// ShouldUpdate checks if CLI version should be updated on the channel of the --channel flag or the configuration
 func (o *CLIOptions) ShouldUpdate(newVersion semver.Version) (bool, error) {
 	log.Logger().Debugf("Checking if should upgrade %s", newVersion)
 	currentVersion, err := version.GetSemverVersion()
 	if err != nil {
 		return false, err
 	}
+	channel, _, err := o.resolveChannel()
+	if err != nil {
+		return false, err
+	}
 
 	if newVersion.GT(currentVersion) {
-		// Do not ask to update if we are using a dev build...
-		for _, x := range currentVersion.Pre {
-			if x.VersionStr == "dev" {
-				log.Logger().Debugf("Ignoring possible update as it appears you are using a dev build - %s", currentVersion)
-				return false, nil
-			}
+		reason := updateBlocker(channel, currentVersion, newVersion)
+		if reason != "" {
+			log.Logger().Debugf("Ignoring possible update to %s as %s", newVersion, reason)
+			return false, nil
 		}
 		return true, nil
 	}
 	return false, nil
 }
 
+// updateBlocker returns the reason why the newer version should not be installed on the channel or an empty string
+func updateBlocker(channel string, currentVersion, newVersion semver.Version) string {
+	// Do not ask to update if we are using a dev build...
+	if hasPreRelease(currentVersion, "dev") {
+		return "the current version is a dev build"
+	}
+	if !channelAllows(channel, newVersion) {
+		return fmt.Sprintf("the resolved version is a pre-release which is not published on the %s channel", channel)
+	}
+	return ""
+}
+
 // InstallJx installs jx cli
 func (o *CLIOptions) InstallJx(upgrade bool, version string) error {
 	log.Logger().Debugf("installing jx %s", version)
@@ -234,38 +464,91 @@ func (o *CLIOptions) InstallJx(upgrade bool, version string) error {
 		}
 	}
 
-	extension := "tar.gz"
-	if runtime.GOOS == "windows" {
-		extension = "zip"
+	clientURL, err := o.DownloadURL(version)
+	if err != nil {
+		return err
 	}
+	exe, err := o.executable()
+	if err != nil {
+		return err
+	}
+
+	// a downgrade restores the binary kept by an earlier upgrade if there is one rather than downloading it again
+	restored, err := o.restoreDowngradeBackup(exe, version)
+	if err != nil || restored {
+		return err
+	}
+
+	err = checkReleaseExists(clientURL)
+	if err != nil {
+		return err
+	}
+
 	log.Logger().Infof("downloading version %s...", version)
-	clientURL := fmt.Sprintf("%s%s/"+binary+"-%s-%s.%s", BinaryDownloadBaseURL, version, runtime.GOOS, runtime.GOARCH, extension)
-	exe, err := os.Executable()
+	tmpDir, err := ioutil.TempDir("", "jx-upgrade-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// verify the release before touching the current binary
+	archive, err := o.downloadVerifiedArchive(clientURL, tmpDir)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get the jx executable which is running this command")
+		return errors.Wrapf(err, "failed to download jx cli version %s", version)
 	}
 
-	err = selfupdate.UpdateTo(clientURL, exe)
+	// keep the current binary so that a bad upgrade can be rolled back
+	currentVersion, backup, err := backupCurrentBinary(exe)
+	if err != nil {
+		return err
+	}
+
+	err = applyArchive(archive, clientURL, exe)
 	if err != nil {
 		return errors.Wrapf(err, "failed to upgrade jx cli to version %s", version)
 	}
-	log.Logger().Infof("Jenkins X client has been upgraded to version %s", version)
-	return nil
+	action := historyAction(currentVersion, version)
+	err = recordHistory(action, currentVersion, version, backup)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Jenkins X client has been %sd to version %s. Use 'jx upgrade cli --rollback' to restore version %s", action, version, currentVersion)
+	return pruneBackups(o.keepBackups())
 }
 
-func (o *CLIOptions) getJXVersion(gitURL string) (string, error) {
+// DownloadURL returns the URL of the jx release archive of the given version for the current platform
+func (o *CLIOptions) DownloadURL(version string) (string, error) {
+	if u := o.assetURLs[version]; u != "" {
+		return u, nil
+	}
+	source, err := o.releaseSource()
+	if err != nil {
+		return "", err
+	}
+	return source.DownloadURL(version)
+}
+
+func (o *CLIOptions) getJXVersion(gitURL, ref, directory string) (string, error) {
 	if o.GitClient == nil {
 		o.GitClient = cli.NewCLIClient("", cmdrunner.QuietCommandRunner)
 	}
 
-	versionStreamDir, err := gitclient.CloneToDir(o.GitClient, gitURL, "")
+	cache := &versionstreams.Cache{GitClient: o.GitClient}
+	versionStreamDir, unlock, err := cache.Resolve(gitURL, ref)
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to clone git repo %s", gitURL)
+		return "", errors.Wrapf(err, "failed to fetch git repo %s", gitURL)
 	}
-
-	exists, _ := files.DirExists(filepath.Join(versionStreamDir, "versionStream"))
-	if exists {
-		versionStreamDir = filepath.Join(versionStreamDir, "versionStream")
+	// lets keep the cached clone locked while we read it
+	defer unlock()
+
+	directory = strings.Trim(directory, "/")
+	if directory != "" && directory != "." {
+		versionStreamDir = filepath.Join(versionStreamDir, filepath.FromSlash(directory))
+	} else {
+		exists, _ := files.DirExists(filepath.Join(versionStreamDir, "versionStream"))
+		if exists {
+			versionStreamDir = filepath.Join(versionStreamDir, "versionStream")
+		}
 	}
 
 	resolver := &versionstream.VersionResolver{
@@ -0,0 +1,453 @@
+package upgrade
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/pkg/errors"
+)
+
+const (
+	// BackupsDirName the directory inside the jx home dir containing the previous jx binaries indexed by version
+	BackupsDirName = "cli-backups"
+	// HistoryFileName the file inside the jx home dir recording the upgrades and rollbacks of the jx binary
+	HistoryFileName = "cli-history.yaml"
+	// DefaultKeepBackups the default number of previous jx binaries to keep
+	DefaultKeepBackups = 3
+
+	// ActionUpgrade the history action for an upgrade
+	ActionUpgrade = "upgrade"
+	// ActionDowngrade the history action for a downgrade
+	ActionDowngrade = "downgrade"
+	// ActionRollback the history action for a rollback
+	ActionRollback = "rollback"
+)
+
+// History the upgrades and rollbacks of the jx binary
+type History struct {
+	// Entries the upgrades and rollbacks, oldest first
+	Entries []HistoryEntry `json:"entries,omitempty"`
+}
+
+// HistoryEntry an upgrade or rollback of the jx binary
+type HistoryEntry struct {
+	// Time when the binary was replaced
+	Time time.Time `json:"time"`
+	// Action either ActionUpgrade, ActionDowngrade or ActionRollback
+	Action string `json:"action"`
+	// From the version which was replaced
+	From string `json:"from"`
+	// To the version which was installed
+	To string `json:"to"`
+	// Backup the path of the backup of the replaced binary
+	Backup string `json:"backup,omitempty"`
+}
+
+// Backup a previous jx binary which can be restored via a rollback
+type Backup struct {
+	// Version the version of the binary
+	Version string `json:"version"`
+	// Path the path of the binary
+	Path string `json:"path"`
+	// Time when the backup was taken
+	Time time.Time `json:"time"`
+}
+
+// BackupsDir returns the directory containing the previous jx binaries
+func BackupsDir() (string, error) {
+	dir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, BackupsDirName), nil
+}
+
+// LoadHistory loads the upgrade history returning an empty history if there is none
+func LoadHistory() (*History, error) {
+	path, err := historyFile()
+	if err != nil {
+		return nil, err
+	}
+	h := &History{}
+	err = yamls.LoadFile(path, h)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load upgrade history %s", path)
+	}
+	return h, nil
+}
+
+// Save saves the upgrade history
+func (h *History) Save() error {
+	path, err := historyFile()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir for %s", path)
+	}
+	err = yamls.SaveFile(h, path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save upgrade history %s", path)
+	}
+	return nil
+}
+
+func historyFile() (string, error) {
+	dir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, HistoryFileName), nil
+}
+
+// recordHistory appends an entry to the upgrade history
+func recordHistory(action, from, to, backup string) error {
+	h, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	h.Entries = append(h.Entries, HistoryEntry{
+		Time:   time.Now().UTC(),
+		Action: action,
+		From:   from,
+		To:     to,
+		Backup: backup,
+	})
+	return h.Save()
+}
+
+// ListBackups returns the previous jx binaries, most recent first
+func ListBackups() ([]Backup, error) {
+	dir, err := BackupsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read backups dir %s", dir)
+	}
+	var answer []Backup
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name(), BinaryWithExtension("jx"))
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		answer = append(answer, Backup{
+			Version: e.Name(),
+			Path:    path,
+			Time:    info.ModTime(),
+		})
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		if !answer[i].Time.Equal(answer[j].Time) {
+			return answer[i].Time.After(answer[j].Time)
+		}
+		vi, erri := semver.Parse(answer[i].Version)
+		vj, errj := semver.Parse(answer[j].Version)
+		if erri != nil || errj != nil {
+			return answer[i].Version > answer[j].Version
+		}
+		return vi.GT(vj)
+	})
+	return answer, nil
+}
+
+// backupBinary copies the binary of the given version into the backups dir returning the path of the backup
+func backupBinary(exe, binaryVersion string) (string, error) {
+	dir, err := BackupsDir()
+	if err != nil {
+		return "", err
+	}
+	versionDir := filepath.Join(dir, binaryVersion)
+	err = os.MkdirAll(versionDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create backup dir %s", versionDir)
+	}
+	path := filepath.Join(versionDir, BinaryWithExtension("jx"))
+	err = copyBinary(exe, path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to backup %s", exe)
+	}
+	return path, nil
+}
+
+// historyAction returns ActionDowngrade if the new version is older than the current version otherwise ActionUpgrade
+func historyAction(from, to string) string {
+	fromVersion, err := semver.ParseTolerant(from)
+	if err != nil {
+		return ActionUpgrade
+	}
+	toVersion, err := semver.ParseTolerant(to)
+	if err != nil {
+		return ActionUpgrade
+	}
+	if toVersion.LT(fromVersion) {
+		return ActionDowngrade
+	}
+	return ActionUpgrade
+}
+
+// restoreDowngradeBackup restores the backup of the version when downgrading to a version kept by an earlier upgrade.
+// Returns false if this is not a downgrade or there is no backup of the version
+func (o *CLIOptions) restoreDowngradeBackup(exe, toVersion string) (bool, error) {
+	currentVersion := version.GetVersion()
+	if historyAction(currentVersion, toVersion) != ActionDowngrade {
+		return false, nil
+	}
+	backups, err := ListBackups()
+	if err != nil {
+		return false, err
+	}
+	for _, b := range backups {
+		if b.Version != toVersion {
+			continue
+		}
+		_, current, err := backupCurrentBinary(exe)
+		if err != nil {
+			return false, err
+		}
+		err = restoreBinary(b.Path, exe)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to downgrade jx to version %s", toVersion)
+		}
+		err = recordHistory(ActionDowngrade, currentVersion, toVersion, current)
+		if err != nil {
+			return false, err
+		}
+		log.Logger().Infof("Jenkins X client has been downgraded to version %s from the backup of an earlier upgrade. Use 'jx upgrade cli --rollback' to restore version %s", toVersion, currentVersion)
+		return true, pruneBackups(o.keepBackups())
+	}
+	return false, nil
+}
+
+// backupCurrentBinary backs up the executable of the running version returning the version and the path of the backup
+func backupCurrentBinary(exe string) (string, string, error) {
+	currentVersion := version.GetVersion()
+	path, err := backupBinary(exe, currentVersion)
+	return currentVersion, path, err
+}
+
+// pruneBackups removes all but the most recent backups
+func pruneBackups(keep int) error {
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		dir := filepath.Dir(backups[i].Path)
+		err = os.RemoveAll(dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove old backup %s", dir)
+		}
+		log.Logger().Debugf("removed old backup of jx %s", backups[i].Version)
+	}
+	return nil
+}
+
+// restoreBinary replaces the executable with the given binary. The binary is copied next to the executable
+// first and then renamed over it so that the executable is replaced atomically and is never left partially written
+func restoreBinary(src, exe string) error {
+	dir := filepath.Dir(exe)
+	name := filepath.Base(exe)
+	newPath := filepath.Join(dir, "."+name+".new")
+
+	err := copyBinary(src, newPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(newPath)
+
+	if runtime.GOOS != "windows" {
+		err = os.Rename(newPath, exe)
+		if err != nil {
+			return errors.Wrapf(err, "failed to replace %s", exe)
+		}
+		return nil
+	}
+
+	// windows does not allow a running executable to be replaced so move it out of the way first
+	oldPath := filepath.Join(dir, "."+name+".old")
+	_ = os.Remove(oldPath)
+	err = os.Rename(exe, oldPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to move %s to %s", exe, oldPath)
+	}
+	err = os.Rename(newPath, exe)
+	if err != nil {
+		rerr := os.Rename(oldPath, exe)
+		if rerr != nil {
+			return errors.Wrapf(err, "failed to replace %s and failed to restore it from %s: %s", exe, oldPath, rerr.Error())
+		}
+		return errors.Wrapf(err, "failed to replace %s", exe)
+	}
+	// may fail if the old executable is still running
+	_ = os.Remove(oldPath)
+	return nil
+}
+
+func copyBinary(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dest)
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return errors.Wrapf(err, "failed to copy %s to %s", src, dest)
+	}
+	err = out.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", dest)
+	}
+	return nil
+}
+
+// executable returns the path of the jx binary to upgrade
+func (o *CLIOptions) executable() (string, error) {
+	exe := o.Executable
+	if exe == "" {
+		var err error
+		exe, err = os.Executable()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get the jx executable which is running this command")
+		}
+	}
+	// replace the binary rather than a symlink to it
+	resolved, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve symlinks of %s", exe)
+	}
+	return resolved, nil
+}
+
+// RollbackJx restores a previous jx binary
+func (o *CLIOptions) RollbackJx() error {
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	currentVersion := version.GetVersion()
+
+	var backup *Backup
+	for i := range backups {
+		b := &backups[i]
+		if o.RollbackTo != "" {
+			if b.Version == o.RollbackTo {
+				backup = b
+				break
+			}
+		} else if b.Version != currentVersion {
+			backup = b
+			break
+		}
+	}
+	if backup == nil {
+		var versions []string
+		for _, b := range backups {
+			versions = append(versions, b.Version)
+		}
+		if o.RollbackTo != "" {
+			return errors.Errorf("no backup of jx %s found. Available versions: %v", o.RollbackTo, versions)
+		}
+		return errors.Errorf("no previous version of jx to rollback to. Available versions: %v", versions)
+	}
+
+	exe, err := o.executable()
+	if err != nil {
+		return err
+	}
+
+	// keep the current binary so that the rollback can be undone
+	_, current, err := backupCurrentBinary(exe)
+	if err != nil {
+		return err
+	}
+	err = restoreBinary(backup.Path, exe)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rollback jx to version %s", backup.Version)
+	}
+	err = recordHistory(ActionRollback, currentVersion, backup.Version, current)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Jenkins X client has been rolled back to version %s", termcolor.ColorInfo(backup.Version))
+	return pruneBackups(o.keepBackups())
+}
+
+func (o *CLIOptions) keepBackups() int {
+	if o.KeepBackups <= 0 {
+		return DefaultKeepBackups
+	}
+	return o.KeepBackups
+}
+
+// printHistory prints the upgrade history and the available backups
+func (o *CLIOptions) printHistory() error {
+	h, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	if o.Output != nil {
+		var items []interface{}
+		for _, e := range h.Entries {
+			items = append(items, e)
+		}
+		result, err := output.NewResult("UpgradeHistory", []string{"time", "action", "from", "to"}, items...)
+		if err != nil {
+			return err
+		}
+		return output.Render(o.out(), o.Output, []output.Result{*result})
+	}
+
+	if len(h.Entries) == 0 {
+		log.Logger().Infof("jx has not been upgraded yet")
+	} else {
+		t := table.CreateTable(o.out())
+		t.AddRow("TIME", "ACTION", "FROM", "TO")
+		for _, e := range h.Entries {
+			t.AddRow(e.Time.Local().Format(time.RFC3339), e.Action, e.From, e.To)
+		}
+		t.Render()
+	}
+	if len(backups) > 0 {
+		var versions []string
+		for _, b := range backups {
+			versions = append(versions, b.Version)
+		}
+		log.Logger().Infof("versions available to rollback to: %s", termcolor.ColorInfo(strings.Join(versions, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package upgrade
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createBackup(t *testing.T, tmpDir, binaryVersion string, age time.Duration) {
+	src := filepath.Join(tmpDir, "jx-"+binaryVersion)
+	require.NoError(t, ioutil.WriteFile(src, []byte(binaryVersion), 0755))
+	path, err := backupBinary(src, binaryVersion)
+	require.NoError(t, err)
+	when := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, when, when))
+}
+
+func TestRollback(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	version.Map["version"] = "3.2.0"
+	defer delete(version.Map, "version")
+
+	exe := filepath.Join(tmpDir, "bin", "jx")
+	require.NoError(t, os.MkdirAll(filepath.Dir(exe), 0755))
+	require.NoError(t, ioutil.WriteFile(exe, []byte("3.2.0"), 0755))
+
+	createBackup(t, tmpDir, "3.0.0", 2*time.Hour)
+	createBackup(t, tmpDir, "3.1.0", time.Hour)
+
+	o := &CLIOptions{
+		Executable: exe,
+		Rollback:   true,
+		RollbackTo: "9.9.9",
+	}
+	err = o.Run()
+	require.Error(t, err, "should fail to rollback to a missing version")
+	assert.Contains(t, err.Error(), "no backup of jx 9.9.9 found")
+
+	// rollback to the most recent backup
+	o.RollbackTo = ""
+	require.NoError(t, o.Run())
+	assertFileContents(t, exe, "3.1.0")
+
+	backups, err := ListBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+	assert.Equal(t, "3.2.0", backups[0].Version, "the replaced binary should be the most recent backup")
+	assertFileContents(t, backups[0].Path, "3.2.0")
+
+	// rollback to a specific version and prune the oldest backup
+	version.Map["version"] = "3.1.0"
+	o.RollbackTo = "3.0.0"
+	o.KeepBackups = 2
+	require.NoError(t, o.Run())
+	assertFileContents(t, exe, "3.0.0")
+
+	backups, err = ListBackups()
+	require.NoError(t, err)
+	var versions []string
+	for _, b := range backups {
+		versions = append(versions, b.Version)
+	}
+	assert.ElementsMatch(t, []string{"3.1.0", "3.2.0"}, versions, "backups after pruning")
+
+	h, err := LoadHistory()
+	require.NoError(t, err)
+	require.Len(t, h.Entries, 2)
+	assert.Equal(t, ActionRollback, h.Entries[0].Action)
+	assert.Equal(t, "3.2.0", h.Entries[0].From)
+	assert.Equal(t, "3.1.0", h.Entries[0].To)
+	assert.Equal(t, "3.1.0", h.Entries[1].From)
+	assert.Equal(t, "3.0.0", h.Entries[1].To)
+
+	out := &bytes.Buffer{}
+	o = &CLIOptions{History: true, Out: out}
+	o.Output, err = output.ParseFormat("jsonpath={[*].to}")
+	require.NoError(t, err)
+	require.NoError(t, o.Run())
+	assert.Equal(t, "3.1.0 3.0.0\n", out.String(), "the history should be rendered to the output writer")
+
+	o = &CLIOptions{RollbackTo: "3.1.0"}
+	require.Error(t, o.Run(), "--to requires --rollback")
+}
+
+func assertFileContents(t *testing.T, path, expected string) {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data), "contents of %s", path)
+}
+
+func TestDowngradeFromBackup(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	version.Map["version"] = "3.3.0"
+	defer delete(version.Map, "version")
+
+	exe := filepath.Join(tmpDir, "bin", "jx")
+	require.NoError(t, os.MkdirAll(filepath.Dir(exe), 0755))
+	require.NoError(t, ioutil.WriteFile(exe, []byte("3.3.0"), 0755))
+	createBackup(t, tmpDir, "3.2.0", time.Hour)
+
+	o := &CLIOptions{
+		CommandRunner: notOwnedRunner,
+		Executable:    exe,
+		Version:       "3.2.0",
+		DryRun:        true,
+	}
+	report, err := o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, DecisionNoOp, report.Decision, "downgrades should not be allowed by default")
+
+	o.AllowDowngrade = true
+	report, err = o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, DecisionDowngrade, report.Decision)
+	assert.Contains(t, report.Reason, "downgrades are allowed")
+
+	o.DryRun = false
+	require.NoError(t, o.Run())
+	assertFileContents(t, exe, "3.2.0")
+
+	h, err := LoadHistory()
+	require.NoError(t, err)
+	require.Len(t, h.Entries, 1)
+	assert.Equal(t, ActionDowngrade, h.Entries[0].Action)
+	assert.Equal(t, "3.3.0", h.Entries[0].From)
+	assert.Equal(t, "3.2.0", h.Entries[0].To)
+
+	backups, err := ListBackups()
+	require.NoError(t, err)
+	require.NotEmpty(t, backups)
+	assert.Equal(t, "3.3.0", backups[0].Version, "the newer binary should be kept so the downgrade can be rolled back")
+}
@@ -0,0 +1,259 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChannelStable resolves the version of jx from the version stream
+	ChannelStable = "stable"
+	// ChannelBeta resolves the latest version published in the release feed including pre-releases other than nightly and dev builds
+	ChannelBeta = "beta"
+	// ChannelNightly resolves the latest version published in the nightly feed configured via `upgrade.nightlyFeedURL`
+	ChannelNightly = "nightly"
+
+	// DefaultReleaseFeedURL the default release feed used by the beta channel
+	DefaultReleaseFeedURL = "https://api.github.com/repos/jenkins-x/jx/releases?per_page=100"
+)
+
+// Channels the supported release channels
+var Channels = []string{ChannelStable, ChannelBeta, ChannelNightly}
+
+// FeedRelease a release in a release feed in the format of the GitHub releases API
+type FeedRelease struct {
+	// TagName the tag of the release such as `v3.2.1-beta.1`
+	TagName string `json:"tag_name"`
+	// Name the title of the release
+	Name string `json:"name,omitempty"`
+	// Body the release notes in markdown
+	Body string `json:"body,omitempty"`
+	// HTMLURL the URL of the release page
+	HTMLURL string `json:"html_url,omitempty"`
+	// Draft draft releases are ignored
+	Draft bool `json:"draft,omitempty"`
+	// Assets the files of the release
+	Assets []FeedAsset `json:"assets,omitempty"`
+}
+
+// FeedAsset a file of a release in a release feed
+type FeedAsset struct {
+	// Name the file name such as `jx-linux-amd64.tar.gz`
+	Name string `json:"name"`
+	// BrowserDownloadURL the URL to download the file from
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// resolveChannel returns the release channel from the flag or from the configuration
+func (o *CLIOptions) resolveChannel() (string, *config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", nil, err
+	}
+	channel := o.Channel
+	if channel == "" {
+		channel = cfg.Upgrade.Channel
+	}
+	if channel == "" {
+		return ChannelStable, cfg, nil
+	}
+	if stringhelpers.StringArrayIndex(Channels, channel) < 0 {
+		return "", nil, errors.Errorf("unsupported channel %s; supported channels are: %s", channel, strings.Join(Channels, ", "))
+	}
+	return channel, cfg, nil
+}
+
+// saveChannel saves the channel of the flag in the configuration so it is used for future upgrades.
+// It is only called once the channel has resolved a version so a channel which cannot be used is not saved
+func (o *CLIOptions) saveChannel(cfg *config.Config) error {
+	if o.Channel == "" || o.Channel == cfg.Upgrade.Channel || o.DryRun {
+		return nil
+	}
+	cfg.Upgrade.Channel = o.Channel
+	err := cfg.Save()
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the channel")
+	}
+	log.Logger().Infof("using the %s channel for future upgrades", termcolor.ColorInfo(o.Channel))
+	return nil
+}
+
+// feedURL returns the URL of the release feed of the channel
+func (o *CLIOptions) feedURL(channel string, cfg *config.Config) (string, error) {
+	if channel == ChannelNightly {
+		if cfg.Upgrade.NightlyFeedURL == "" {
+			return "", errors.Errorf("no nightly feed is configured. Please set upgrade.nightlyFeedURL in the jx config file")
+		}
+		return cfg.Upgrade.NightlyFeedURL, nil
+	}
+	source, err := o.releaseSource()
+	if err != nil {
+		return "", err
+	}
+	return source.FeedURL, nil
+}
+
+// maxFeedPages the maximum number of pages of a release feed which are read
+const maxFeedPages = 20
+
+// nextLinkPattern matches the URL of the next page in a `Link` header of the GitHub API
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// loadFeed loads the first page of releases from the release feed which is either a URL in the format of the
+// GitHub releases API or a local file in the same format such as a stand-in for air-gapped sites
+func loadFeed(feedURL string) ([]FeedRelease, error) {
+	releases, _, err := loadFeedUntil(feedURL, nil)
+	return releases, err
+}
+
+// loadFeedUntil loads the releases from the release feed following the `Link` header to the next pages until a page
+// contains a release at or before the given version. Returns false if the feed was truncated before the version was found
+func loadFeedUntil(feedURL string, until *semver.Version) ([]FeedRelease, bool, error) {
+	if strings.HasPrefix(feedURL, "file://") || !strings.Contains(feedURL, "://") {
+		path := strings.TrimPrefix(feedURL, "file://")
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to read the release feed %s", path)
+		}
+		releases, err := parseFeed(data, feedURL)
+		return releases, true, err
+	}
+
+	var answer []FeedRelease
+	pageURL := feedURL
+	for page := 0; page < maxFeedPages; page++ {
+		data, header, found, err := downloadWithHeader(pageURL)
+		if err != nil {
+			return nil, false, err
+		}
+		if !found {
+			return nil, false, errors.Errorf("no release feed found at %s", pageURL)
+		}
+		releases, err := parseFeed(data, pageURL)
+		if err != nil {
+			return nil, false, err
+		}
+		answer = append(answer, releases...)
+		if until == nil || feedContains(releases, *until) {
+			return answer, true, nil
+		}
+		m := nextLinkPattern.FindStringSubmatch(header.Get("Link"))
+		if m == nil {
+			return answer, true, nil
+		}
+		pageURL = m[1]
+	}
+	return answer, false, nil
+}
+
+// feedContains returns true if any of the releases are at or before the version
+func feedContains(releases []FeedRelease, version semver.Version) bool {
+	for _, r := range releases {
+		v, err := semver.ParseTolerant(r.TagName)
+		if err == nil && v.LTE(version) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseFeed(data []byte, feedURL string) ([]FeedRelease, error) {
+	var releases []FeedRelease
+	err := json.Unmarshal(data, &releases)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the release feed %s", feedURL)
+	}
+	return releases, nil
+}
+
+// feedVersion resolves the latest version on the beta or nightly channel from its release feed
+func (o *CLIOptions) feedVersion(channel string, cfg *config.Config, report *Report) (string, error) {
+	feedURL, err := o.feedURL(channel, cfg)
+	if err != nil {
+		return "", err
+	}
+	releases, err := loadFeed(feedURL)
+	if err != nil {
+		return "", err
+	}
+
+	var latest *semver.Version
+	var latestRelease FeedRelease
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		v, err := semver.ParseTolerant(r.TagName)
+		if err != nil {
+			log.Logger().Debugf("ignoring release %s in %s which is not a semantic version", r.TagName, feedURL)
+			continue
+		}
+		if !channelAllows(channel, v) {
+			continue
+		}
+		if latest == nil || v.GT(*latest) {
+			latest = &v
+			latestRelease = r
+		}
+	}
+	if latest == nil {
+		return "", errors.Errorf("no releases for the %s channel found in %s", channel, feedURL)
+	}
+
+	// prefer the download URL published in the feed
+	answer := latest.String()
+	source, err := o.releaseSource()
+	if err != nil {
+		return "", err
+	}
+	archive, err := source.AssetName(answer)
+	if err != nil {
+		return "", err
+	}
+	for _, a := range latestRelease.Assets {
+		if a.Name == archive && a.BrowserDownloadURL != "" {
+			if o.assetURLs == nil {
+				o.assetURLs = map[string]string{}
+			}
+			o.assetURLs[answer] = a.BrowserDownloadURL
+		}
+	}
+
+	report.VersionStreamSource = SourceReleaseFeed
+	report.VersionStreamReason = fmt.Sprintf("the %s channel resolves the latest version from its release feed", channel)
+	report.VersionStreamURL = feedURL
+	return answer, nil
+}
+
+// channelAllows returns true if the version is published on the channel.
+// Stable only has releases, beta also has pre-releases other than nightly and dev builds and nightly has everything
+func channelAllows(channel string, v semver.Version) bool {
+	switch channel {
+	case ChannelNightly:
+		return true
+	case ChannelBeta:
+		return !hasPreRelease(v, "nightly") && !hasPreRelease(v, "dev")
+	default:
+		return len(v.Pre) == 0
+	}
+}
+
+// hasPreRelease returns true if the version has a pre-release identifier starting with the prefix such as `dev` or `nightly`
+func hasPreRelease(v semver.Version, prefix string) bool {
+	for _, p := range v.Pre {
+		if !p.IsNum && strings.HasPrefix(p.VersionStr, prefix) {
+			return true
+		}
+	}
+	return false
+}
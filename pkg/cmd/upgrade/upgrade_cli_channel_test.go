@@ -0,0 +1,138 @@
+package upgrade
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannels(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	version.Map["version"] = "3.2.0"
+	defer delete(version.Map, "version")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases":
+			w.Write([]byte(`[
+  {"tag_name": "v3.4.0-beta.1", "draft": true},
+  {"tag_name": "v3.3.0-nightly.20261017"},
+  {"tag_name": "v3.3.0-beta.2"},
+  {"tag_name": "v3.3.0-beta.1"},
+  {"tag_name": "latest"},
+  {"tag_name": "v3.2.0"}
+]`))
+		case "/nightly":
+			w.Write([]byte(`[
+  {"tag_name": "v3.3.0-nightly.20261018", "assets": [{"name": "jx-` + runtime.GOOS + `-` + runtime.GOARCH + `.tar.gz", "browser_download_url": "https://nightly.example.com/jx.tar.gz"}]},
+  {"tag_name": "v3.3.0-nightly.20261017"}
+]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Upgrade: config.UpgradeConfig{ReleaseFeedURL: server.URL + "/releases"}}
+	require.NoError(t, cfg.Save())
+
+	o := &CLIOptions{Channel: "beta"}
+	report, err := o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, ChannelBeta, report.Channel)
+	assert.Equal(t, SourceReleaseFeed, report.VersionStreamSource)
+	assert.Equal(t, "3.3.0-beta.2", report.Version)
+	assert.Equal(t, DecisionUpgrade, report.Decision)
+	assert.Equal(t, "https://github.com/jenkins-x/jx/releases/download/v3.3.0-beta.2/jx-"+runtime.GOOS+"-"+runtime.GOARCH+".tar.gz", report.DownloadURL)
+
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, ChannelBeta, cfg.Upgrade.Channel, "the channel should be saved")
+
+	o = &CLIOptions{Channel: "nightly"}
+	_, err = o.Resolve()
+	require.Error(t, err, "there is no nightly feed")
+	assert.Contains(t, err.Error(), "no nightly feed is configured")
+
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, ChannelBeta, cfg.Upgrade.Channel, "a channel which cannot be resolved should not be saved")
+
+	o = &CLIOptions{Channel: "nightly", DryRun: true}
+
+	cfg.Upgrade.NightlyFeedURL = server.URL + "/nightly"
+	require.NoError(t, cfg.Save())
+	report, err = o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, "3.3.0-nightly.20261018", report.Version)
+	assert.Equal(t, "https://nightly.example.com/jx.tar.gz", report.DownloadURL)
+
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, ChannelBeta, cfg.Upgrade.Channel, "a dry run should not change the channel")
+
+	o = &CLIOptions{Channel: "alpha"}
+	_, err = o.Resolve()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported channel alpha")
+}
+
+func TestShouldUpdateChannels(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	testCases := []struct {
+		channel  string
+		current  string
+		newer    string
+		expected bool
+	}{
+		{channel: "", current: "3.2.0", newer: "3.3.0", expected: true},
+		{channel: ChannelStable, current: "3.2.0", newer: "3.3.0-beta.1", expected: false},
+		{channel: ChannelBeta, current: "3.2.0", newer: "3.3.0-beta.1", expected: true},
+		{channel: ChannelBeta, current: "3.3.0-beta.1", newer: "3.3.0-beta.2", expected: true},
+		{channel: ChannelBeta, current: "3.2.0", newer: "3.3.0-nightly.20261018", expected: false},
+		{channel: ChannelNightly, current: "3.3.0-nightly.20261017", newer: "3.3.0-nightly.20261018", expected: true},
+		{channel: ChannelNightly, current: "3.2.0-dev+6a8285f4", newer: "3.3.0", expected: false},
+	}
+	defer delete(version.Map, "version")
+	for _, tc := range testCases {
+		version.Map["version"] = tc.current
+		o := &CLIOptions{Channel: tc.channel}
+		update, err := o.ShouldUpdate(semver.MustParse(tc.newer))
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, update, "channel %s from %s to %s", tc.channel, tc.current, tc.newer)
+	}
+
+	// the channel saved in the configuration is used if there is no flag
+	cfg := &config.Config{Upgrade: config.UpgradeConfig{Channel: ChannelBeta}}
+	require.NoError(t, cfg.Save())
+	version.Map["version"] = "3.2.0"
+	o := &CLIOptions{}
+	update, err := o.ShouldUpdate(semver.MustParse("3.3.0-beta.1"))
+	require.NoError(t, err)
+	assert.True(t, update, "should use the beta channel from the configuration")
+
+	o = &CLIOptions{Channel: "alpha"}
+	_, err = o.ShouldUpdate(semver.MustParse("3.3.0"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported channel alpha")
+}
@@ -0,0 +1,215 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/pkg/errors"
+)
+
+const (
+	// InstallMethodBinary jx was installed by downloading the binary so it can replace itself
+	InstallMethodBinary = "binary"
+	// InstallMethodBrew jx was installed via Homebrew
+	InstallMethodBrew = "brew"
+	// InstallMethodAsdf jx was installed via asdf
+	InstallMethodAsdf = "asdf"
+	// InstallMethodDpkg jx is owned by a Debian package
+	InstallMethodDpkg = "dpkg"
+	// InstallMethodRpm jx is owned by an RPM package
+	InstallMethodRpm = "rpm"
+	// InstallMethodReadOnly jx is in a directory which is not writable such as in a container image
+	InstallMethodReadOnly = "read-only"
+)
+
+// Installation describes how jx was installed and so how it should be upgraded
+type Installation struct {
+	// Method how jx was installed such as InstallMethodBrew
+	Method string
+	// Path the path of the jx binary after resolving symlinks
+	Path string
+	// Package the name of the package which installed jx
+	Package string
+	// Commands the package manager commands which upgrade jx or empty if jx cannot be upgraded automatically
+	Commands []*cmdrunner.Command
+	// Instructions what the user needs to do to upgrade jx if it cannot be upgraded automatically
+	Instructions string
+}
+
+// DetectInstallation works out how the jx binary was installed so that upgrades to the given version can be
+// delegated to the package manager which owns it
+func (o *CLIOptions) DetectInstallation(exe, toVersion string) (*Installation, error) {
+	resolved, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve symlinks of %s", exe)
+	}
+	slashPath := filepath.ToSlash(resolved)
+	i := &Installation{
+		Method: InstallMethodBinary,
+		Path:   resolved,
+	}
+
+	// homebrew keeps formulae in the Cellar and links the binaries onto the PATH
+	if idx := strings.Index(slashPath, "/Cellar/"); idx >= 0 {
+		i.Method = InstallMethodBrew
+		i.Package = strings.SplitN(slashPath[idx+len("/Cellar/"):], "/", 2)[0]
+		if o.Version != "" || historyAction(version.GetVersion(), toVersion) == ActionDowngrade {
+			i.Instructions = fmt.Sprintf("brew cannot install a specific version of %s. Run 'brew upgrade %s' or use --ignore-package-manager to replace the binary directly", i.Package, i.Package)
+			return i, nil
+		}
+		// brew upgrade always installs the version of the formula so lets check it is the version we resolved
+		brewVersion, err := o.brewFormulaVersion(i.Package)
+		if err != nil {
+			log.Logger().Debugf("failed to find the brew version of %s: %s", i.Package, err.Error())
+			i.Instructions = fmt.Sprintf("could not find which version of %s brew would install rather than %s. Run 'brew upgrade %s' or use --ignore-package-manager to replace the binary directly", i.Package, toVersion, i.Package)
+			return i, nil
+		}
+		if brewVersion != toVersion {
+			i.Instructions = fmt.Sprintf("brew would install %s version %s rather than %s. Run 'brew upgrade %s' to install version %s or use --ignore-package-manager to install %s directly", i.Package, brewVersion, toVersion, i.Package, brewVersion, toVersion)
+			return i, nil
+		}
+		i.Commands = []*cmdrunner.Command{
+			{
+				Name: "brew",
+				Args: []string{"upgrade", i.Package},
+				// lets not update the formulae so that brew installs the version we checked
+				Env: map[string]string{"HOMEBREW_NO_AUTO_UPDATE": "1"},
+			},
+		}
+		return i, nil
+	}
+
+	// asdf shims execute the binaries in its installs dir
+	if isInDir(resolved, filepath.Join(asdfDataDir(), "installs")) || strings.Contains(slashPath, "/.asdf/installs/") {
+		i.Method = InstallMethodAsdf
+		i.Package = "jx"
+		i.Commands = []*cmdrunner.Command{
+			{Name: "asdf", Args: []string{"install", "jx", toVersion}},
+		}
+		i.Instructions = fmt.Sprintf("run 'asdf global jx %s' or 'asdf local jx %s' to use the new version", toVersion, toVersion)
+		return i, nil
+	}
+
+	if runtime.GOOS == "linux" {
+		// dpkg -S prints the owning packages such as `jx: /usr/bin/jx`
+		out, err := o.detectionRunner()(&cmdrunner.Command{Name: "dpkg", Args: []string{"-S", resolved}})
+		if err == nil && strings.Contains(out, ":") {
+			i.Method = InstallMethodDpkg
+			i.Package = strings.TrimSpace(strings.SplitN(out, ":", 2)[0])
+			i.Instructions = fmt.Sprintf("%s is owned by the Debian package %s. Run 'sudo apt-get update && sudo apt-get install --only-upgrade %s' or use --ignore-package-manager to replace the binary directly", resolved, i.Package, i.Package)
+			return i, nil
+		}
+		out, err = o.detectionRunner()(&cmdrunner.Command{Name: "rpm", Args: []string{"-qf", "--queryformat", "%{NAME}", resolved}})
+		out = strings.TrimSpace(out)
+		if err == nil && out != "" && !strings.Contains(out, " ") {
+			i.Method = InstallMethodRpm
+			i.Package = out
+			i.Instructions = fmt.Sprintf("%s is owned by the RPM package %s. Run 'sudo dnf upgrade %s' or use --ignore-package-manager to replace the binary directly", resolved, i.Package, i.Package)
+			return i, nil
+		}
+	}
+
+	dir := filepath.Dir(resolved)
+	if !isWritableDir(dir) {
+		downloadURL, err := o.DownloadURL(toVersion)
+		if err != nil {
+			return nil, err
+		}
+		i.Method = InstallMethodReadOnly
+		i.Instructions = fmt.Sprintf("the directory %s is not writable. Re-run the upgrade as a user who can write to it or download %s and install it somewhere writable on your PATH", dir, downloadURL)
+		return i, nil
+	}
+	return i, nil
+}
+
+// upgradeViaPackageManager runs the package manager commands to upgrade jx or returns an error with the instructions
+func (o *CLIOptions) upgradeViaPackageManager(i *Installation) error {
+	if len(i.Commands) == 0 {
+		return errors.Errorf("cannot upgrade jx as it was installed via %s: %s", i.Method, i.Instructions)
+	}
+	runner := o.CommandRunner
+	if runner == nil {
+		runner = cmdrunner.DefaultCommandRunner
+	}
+	for _, c := range i.Commands {
+		log.Logger().Infof("jx was installed via %s so running: %s", i.Method, termcolor.ColorInfo(c.CLI()))
+		_, err := runner(c)
+		if err != nil {
+			return errors.Wrapf(err, "failed to run %s", c.CLI())
+		}
+	}
+	if i.Instructions != "" {
+		log.Logger().Infof("%s", i.Instructions)
+	}
+	return nil
+}
+
+// brewFormulaVersion returns the stable version of the formula which `brew upgrade` would install
+func (o *CLIOptions) brewFormulaVersion(formula string) (string, error) {
+	out, err := o.detectionRunner()(&cmdrunner.Command{Name: "brew", Args: []string{"info", "--json=v2", formula}})
+	if err != nil {
+		return "", err
+	}
+	info := &struct {
+		Formulae []struct {
+			Versions struct {
+				Stable string `json:"stable"`
+			} `json:"versions"`
+		} `json:"formulae"`
+	}{}
+	err = json.Unmarshal([]byte(out), info)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the output of brew info %s", formula)
+	}
+	if len(info.Formulae) == 0 || info.Formulae[0].Versions.Stable == "" {
+		return "", errors.Errorf("no stable version of formula %s", formula)
+	}
+	return strings.TrimPrefix(info.Formulae[0].Versions.Stable, "v"), nil
+}
+
+func (o *CLIOptions) detectionRunner() cmdrunner.CommandRunner {
+	if o.CommandRunner != nil {
+		return o.CommandRunner
+	}
+	return cmdrunner.QuietCommandRunner
+}
+
+func asdfDataDir() string {
+	dir := os.Getenv("ASDF_DATA_DIR")
+	if dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".asdf")
+}
+
+func isInDir(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isWritableDir returns true if a file can be created in the directory
+func isWritableDir(dir string) bool {
+	f, err := ioutil.TempFile(dir, ".jx-upgrade-")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	_ = os.Remove(f.Name())
+	return true
+}
@@ -0,0 +1,199 @@
+package upgrade
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner/fakerunner"
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createBinary(t *testing.T, path string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte("jx"), 0755))
+}
+
+// notOwnedRunner fails the package manager queries as if no package owns the binary
+func notOwnedRunner(c *cmdrunner.Command) (string, error) {
+	return "", errors.Errorf("%s: no package owns the file", c.CLI())
+}
+
+func TestDetectInstallation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses symlinks")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("ASDF_DATA_DIR", filepath.Join(tmpDir, "asdf"))
+	defer os.Unsetenv("ASDF_DATA_DIR")
+
+	cellarBinary := filepath.Join(tmpDir, "homebrew", "Cellar", "jx", "3.1.0", "bin", "jx")
+	createBinary(t, cellarBinary)
+	brewLink := filepath.Join(tmpDir, "homebrew", "bin", "jx")
+	require.NoError(t, os.MkdirAll(filepath.Dir(brewLink), 0755))
+	require.NoError(t, os.Symlink(cellarBinary, brewLink))
+
+	asdfBinary := filepath.Join(tmpDir, "asdf", "installs", "jx", "3.1.0", "bin", "jx")
+	createBinary(t, asdfBinary)
+
+	binary := filepath.Join(tmpDir, "bin", "jx")
+	createBinary(t, binary)
+
+	runner := &fakerunner.FakeRunner{CommandRunner: brewRunner("3.2.0")}
+	o := &CLIOptions{CommandRunner: runner.Run}
+
+	i, err := o.DetectInstallation(brewLink, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodBrew, i.Method)
+	assert.Equal(t, cellarBinary, i.Path)
+	require.Len(t, i.Commands, 1)
+	assert.Equal(t, "brew upgrade jx", i.Commands[0].CLI())
+
+	i, err = o.DetectInstallation(asdfBinary, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodAsdf, i.Method)
+	require.Len(t, i.Commands, 1)
+	assert.Equal(t, "asdf install jx 3.2.0", i.Commands[0].CLI())
+
+	i, err = o.DetectInstallation(binary, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodBinary, i.Method)
+
+	// brew cannot install a specific version
+	o.Version = "3.2.0"
+	i, err = o.DetectInstallation(brewLink, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodBrew, i.Method)
+	err = o.upgradeViaPackageManager(i)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "brew cannot install a specific version of jx")
+
+	// the version stream pins an older version than the brew formula
+	o.Version = ""
+	o.CommandRunner = brewRunner("3.3.0")
+	i, err = o.DetectInstallation(brewLink, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodBrew, i.Method)
+	assert.Empty(t, i.Commands)
+	err = o.upgradeViaPackageManager(i)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "brew would install jx version 3.3.0 rather than 3.2.0")
+}
+
+// brewRunner fakes brew info reporting the given stable version of the formula
+func brewRunner(stable string) cmdrunner.CommandRunner {
+	return func(c *cmdrunner.Command) (string, error) {
+		if c.CLI() == "brew info --json=v2 jx" {
+			return `{"formulae": [{"name": "jx", "versions": {"stable": "` + stable + `"}}]}`, nil
+		}
+		return notOwnedRunner(c)
+	}
+}
+
+func TestDetectInstallationSystemPackages(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("dpkg and rpm are only checked on linux")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	binary := filepath.Join(tmpDir, "usr", "bin", "jx")
+	createBinary(t, binary)
+
+	runner := &fakerunner.FakeRunner{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			if c.Name == "dpkg" {
+				return "jenkins-x: " + binary + "\n", nil
+			}
+			return notOwnedRunner(c)
+		},
+	}
+	o := &CLIOptions{CommandRunner: runner.Run}
+	i, err := o.DetectInstallation(binary, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodDpkg, i.Method)
+	assert.Equal(t, "jenkins-x", i.Package)
+	err = o.upgradeViaPackageManager(i)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sudo apt-get install --only-upgrade jenkins-x")
+
+	runner = &fakerunner.FakeRunner{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			if c.Name == "rpm" {
+				return "jx", nil
+			}
+			return notOwnedRunner(c)
+		},
+	}
+	o = &CLIOptions{CommandRunner: runner.Run}
+	i, err = o.DetectInstallation(binary, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodRpm, i.Method)
+	assert.Contains(t, i.Instructions, "sudo dnf upgrade jx")
+	runner.ExpectResults(t,
+		fakerunner.FakeResult{CLI: "dpkg -S " + binary},
+		fakerunner.FakeResult{CLI: "rpm -qf --queryformat %{NAME} " + binary},
+	)
+}
+
+func TestUpgradeDelegatesToPackageManager(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+	os.Setenv("ASDF_DATA_DIR", filepath.Join(tmpDir, "asdf"))
+	defer os.Unsetenv("ASDF_DATA_DIR")
+
+	version.Map["version"] = "3.1.0"
+	defer delete(version.Map, "version")
+
+	asdfBinary := filepath.Join(tmpDir, "asdf", "installs", "jx", "3.1.0", "bin", BinaryWithExtension("jx"))
+	createBinary(t, asdfBinary)
+
+	feed := filepath.Join(tmpDir, "releases.json")
+	require.NoError(t, ioutil.WriteFile(feed, []byte(`[{"tag_name": "v3.2.0", "body": "* fix: cheese"}]`), 0600))
+
+	runner := &fakerunner.FakeRunner{}
+	o := &CLIOptions{
+		CommandRunner:  runner.Run,
+		Executable:     asdfBinary,
+		Version:        "3.2.0",
+		ReleaseFeedURL: feed,
+		BatchMode:      true,
+		Out:            &bytes.Buffer{},
+	}
+	require.NoError(t, o.Run())
+	runner.ExpectResults(t, fakerunner.FakeResult{CLI: "asdf install jx 3.2.0"})
+	assertFileContents(t, asdfBinary, "jx")
+}
+
+func TestDetectInstallationReadOnly(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	binary := filepath.Join(tmpDir, "bin", "jx")
+	createBinary(t, binary)
+	require.NoError(t, os.Chmod(filepath.Dir(binary), 0555))
+	defer os.Chmod(filepath.Dir(binary), 0755)
+
+	o := &CLIOptions{CommandRunner: notOwnedRunner}
+	i, err := o.DetectInstallation(binary, "3.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, InstallMethodReadOnly, i.Method)
+	assert.Contains(t, i.Instructions, "is not writable")
+}
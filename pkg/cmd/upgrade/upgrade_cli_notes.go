@@ -0,0 +1,188 @@
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/survey"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/common"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+// breakingChangeMarker matches the lines of release notes which mark a breaking change such as `BREAKING CHANGE: ...`
+// or conventional commits such as `feat(gitops)!: ...`
+var breakingChangeMarker = regexp.MustCompile(`(?i)breaking[ -]changes?\b|\bbreaking:|^\W*[a-z]+(\([^)]*\))?!:`)
+
+// ReleaseNotes the release notes of a version of jx
+type ReleaseNotes struct {
+	// Version the version of the release
+	Version semver.Version
+	// Name the title of the release
+	Name string
+	// URL the URL of the release page
+	URL string
+	// Body the release notes in markdown
+	Body string
+	// BreakingChanges the lines of the release notes which mark breaking changes
+	BreakingChanges []string
+}
+
+// confirmUpgrade shows the release notes between the current and resolved versions and asks to confirm the upgrade.
+// Batch mode shows the release notes and confirms without prompting; otherwise the standard input must be a terminal
+func (o *CLIOptions) confirmUpgrade(report *Report) (bool, error) {
+	notes, feedURL, err := o.releaseNotesBetween(report)
+	if err != nil {
+		log.Logger().Warnf("failed to find the release notes: %s", err.Error())
+	} else {
+		o.printReleaseNotes(notes, feedURL, report)
+	}
+
+	breaking := 0
+	for _, n := range notes {
+		breaking += len(n.BreakingChanges)
+	}
+	if o.BatchMode || common.IsBatchMode(nil, os.Environ()) {
+		return true, nil
+	}
+	if o.Input == nil && !common.IsTerminal(os.Stdin) {
+		return false, errors.Errorf("cannot confirm the upgrade of jx from %s to %s as the standard input is not a terminal. Re-run with --batch-mode to upgrade without prompting", report.CurrentVersion, report.Version)
+	}
+	if o.Input == nil {
+		o.Input = survey.NewInput()
+	}
+	confirmed, err := o.Input.Confirm(fmt.Sprintf("would you like to upgrade jx from %s to %s", report.CurrentVersion, report.Version), breaking == 0, "downloads the new version of jx and replaces the current binary")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to confirm the upgrade")
+	}
+	if !confirmed {
+		log.Logger().Infof("not upgrading jx")
+	}
+	return confirmed, nil
+}
+
+// releaseNotesBetween returns the release notes of the releases after the current version up to the resolved version
+// newest first from the release feed of the channel
+func (o *CLIOptions) releaseNotesBetween(report *Report) ([]*ReleaseNotes, string, error) {
+	current, err := semver.Parse(report.CurrentVersion)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "invalid current version %s", report.CurrentVersion)
+	}
+	target, err := semver.Parse(report.Version)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "invalid version %s", report.Version)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, "", err
+	}
+	feedURL, err := o.feedURL(report.Channel, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	releases, complete, err := loadFeedUntil(feedURL, &current)
+	if err != nil {
+		return nil, feedURL, err
+	}
+	if !complete {
+		log.Logger().Warnf("the release notes are truncated as %s was not found in the first %d pages of %s", report.CurrentVersion, maxFeedPages, feedURL)
+	}
+
+	var answer []*ReleaseNotes
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		v, err := semver.ParseTolerant(r.TagName)
+		if err != nil || !v.GT(current) || v.GT(target) {
+			continue
+		}
+		// skip the pre-releases of other channels unless upgrading to one
+		if !v.EQ(target) && !channelAllows(report.Channel, v) {
+			continue
+		}
+		answer = append(answer, &ReleaseNotes{
+			Version:         v,
+			Name:            r.Name,
+			URL:             r.HTMLURL,
+			Body:            strings.TrimSpace(r.Body),
+			BreakingChanges: breakingChanges(r.Body),
+		})
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Version.GT(answer[j].Version)
+	})
+	return answer, feedURL, nil
+}
+
+// breakingChanges returns the lines of the release notes which mark breaking changes
+func breakingChanges(body string) []string {
+	var answer []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && breakingChangeMarker.MatchString(line) {
+			answer = append(answer, line)
+		}
+	}
+	return answer
+}
+
+// printReleaseNotes renders the release notes followed by a summary of the breaking changes
+func (o *CLIOptions) printReleaseNotes(notes []*ReleaseNotes, feedURL string, report *Report) {
+	out := o.out()
+	if len(notes) == 0 {
+		log.Logger().Infof("no release notes found between %s and %s in %s", report.CurrentVersion, report.Version, feedURL)
+		return
+	}
+	for _, n := range notes {
+		title := "jx " + n.Version.String()
+		if n.Name != "" && strings.TrimPrefix(n.Name, "v") != n.Version.String() {
+			title += " - " + n.Name
+		}
+		fmt.Fprintf(out, "%s\n", termcolor.ColorInfo(title))
+		if n.URL != "" {
+			fmt.Fprintf(out, "%s\n", n.URL)
+		}
+		body := n.Body
+		if body == "" {
+			body = "no release notes"
+		}
+		fmt.Fprintf(out, "\n%s\n\n", indent(body, "  "))
+	}
+
+	printBreakingChanges(out, notes)
+}
+
+func printBreakingChanges(out io.Writer, notes []*ReleaseNotes) {
+	count := 0
+	for _, n := range notes {
+		count += len(n.BreakingChanges)
+	}
+	if count == 0 {
+		fmt.Fprintf(out, "no breaking changes are marked in the release notes of %d release(s)\n", len(notes))
+		return
+	}
+	fmt.Fprintf(out, "%s\n", termcolor.ColorWarning(fmt.Sprintf("%d breaking change(s) are marked in the release notes:", count)))
+	for _, n := range notes {
+		for _, line := range n.BreakingChanges {
+			fmt.Fprintf(out, "  %s: %s\n", n.Version.String(), line)
+		}
+	}
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + strings.TrimRight(line, "\r")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
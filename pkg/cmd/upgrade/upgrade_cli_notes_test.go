@@ -0,0 +1,165 @@
+package upgrade
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testReleaseFeed = `[
+  {"tag_name": "v3.3.0", "body": "* feat: too new"},
+  {"tag_name": "v3.2.1", "draft": true, "body": "* feat: draft"},
+  {"tag_name": "v3.2.0", "name": "Cheese", "html_url": "https://example.com/v3.2.0", "body": "## Changes\n* feat(gitops)!: remove the old boot\n* fix: typo\n\nBREAKING CHANGE: requires helm 3.5"},
+  {"tag_name": "v3.2.0-beta.1", "body": "* feat: beta"},
+  {"tag_name": "v3.1.1", "body": "* fix: something"},
+  {"tag_name": "v3.1.0", "body": "* feat: current"}
+]`
+
+func TestReleaseNotes(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	feed := filepath.Join(tmpDir, "releases.json")
+	require.NoError(t, ioutil.WriteFile(feed, []byte(testReleaseFeed), 0600))
+
+	report := &Report{Channel: ChannelStable, CurrentVersion: "3.1.0", Version: "3.2.0"}
+	for _, feedURL := range []string{feed, "file://" + feed} {
+		o := &CLIOptions{ReleaseFeedURL: feedURL}
+		notes, _, err := o.releaseNotesBetween(report)
+		require.NoError(t, err, "for %s", feedURL)
+		require.Len(t, notes, 2, "for %s", feedURL)
+		assert.Equal(t, "3.2.0", notes[0].Version.String())
+		assert.Equal(t, "3.1.1", notes[1].Version.String())
+		assert.Equal(t, []string{"* feat(gitops)!: remove the old boot", "BREAKING CHANGE: requires helm 3.5"}, notes[0].BreakingChanges)
+		assert.Empty(t, notes[1].BreakingChanges)
+	}
+
+	o := &CLIOptions{ReleaseFeedURL: feed}
+	notes, _, err := o.releaseNotesBetween(&Report{Channel: ChannelBeta, CurrentVersion: "3.1.0", Version: "3.2.0"})
+	require.NoError(t, err)
+	assert.Len(t, notes, 3, "the beta channel should include the beta release notes")
+
+	out := &bytes.Buffer{}
+	o = &CLIOptions{ReleaseFeedURL: feed, Out: out}
+	notes, feedURL, err := o.releaseNotesBetween(report)
+	require.NoError(t, err)
+	o.printReleaseNotes(notes, feedURL, report)
+	assert.Contains(t, out.String(), "jx 3.2.0 - Cheese")
+	assert.Contains(t, out.String(), "https://example.com/v3.2.0")
+	assert.Contains(t, out.String(), "  * fix: typo")
+	assert.Contains(t, out.String(), "2 breaking change(s)")
+	assert.Contains(t, out.String(), "3.2.0: BREAKING CHANGE: requires helm 3.5")
+	assert.NotContains(t, out.String(), "too new")
+	assert.NotContains(t, out.String(), "draft")
+}
+
+func TestReleaseNotesPaginated(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	pages := map[string]string{
+		"1": `[{"tag_name": "v3.2.0", "body": "BREAKING CHANGE: new"}, {"tag_name": "v3.1.2", "body": "* fix: page one"}]`,
+		"2": `[{"tag_name": "v3.1.1", "body": "BREAKING CHANGE: old"}, {"tag_name": "v3.1.0", "body": "* feat: current"}]`,
+		"3": `[{"tag_name": "v3.0.0", "body": "* feat: too old"}]`,
+	}
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		if page != "3" {
+			next := map[string]string{"1": "2", "2": "3"}[page]
+			w.Header().Set("Link", fmt.Sprintf(`<%s/releases?page=%s>; rel="next", <%s/releases?page=3>; rel="last"`, server.URL, next, server.URL))
+		}
+		w.Write([]byte(pages[page]))
+	}))
+	defer server.Close()
+
+	o := &CLIOptions{ReleaseFeedURL: server.URL + "/releases"}
+	notes, _, err := o.releaseNotesBetween(&Report{Channel: ChannelStable, CurrentVersion: "3.1.0", Version: "3.2.0"})
+	require.NoError(t, err)
+	require.Len(t, notes, 3, "should include the notes from the second page")
+	assert.Equal(t, "3.1.1", notes[2].Version.String())
+	assert.Equal(t, []string{"BREAKING CHANGE: old"}, notes[2].BreakingChanges)
+	assert.Equal(t, 2, requests, "should stop paginating once the current version is found")
+}
+
+func TestConfirmUpgrade(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	feed := filepath.Join(tmpDir, "releases.json")
+	require.NoError(t, ioutil.WriteFile(feed, []byte(testReleaseFeed), 0600))
+
+	message := "would you like to upgrade jx from 3.1.0 to 3.2.0"
+	testCases := []struct {
+		name      string
+		batchMode bool
+		values    map[string]string
+		version   string
+		expected  bool
+	}{
+		{name: "batch mode", batchMode: true, version: "3.2.0", expected: true},
+		{name: "accepted", values: map[string]string{message: "yes"}, version: "3.2.0", expected: true},
+		{name: "declined", values: map[string]string{message: "no"}, version: "3.2.0", expected: false},
+		{name: "breaking changes default to no", version: "3.2.0", expected: false},
+		{name: "no breaking changes default to yes", version: "3.1.1", expected: true},
+	}
+	for _, tc := range testCases {
+		o := &CLIOptions{
+			ReleaseFeedURL: feed,
+			BatchMode:      tc.batchMode,
+			Out:            &bytes.Buffer{},
+		}
+		if !tc.batchMode {
+			o.Input = &fake.FakeInput{Values: tc.values}
+		}
+		report := &Report{Channel: ChannelStable, CurrentVersion: "3.1.0", Version: tc.version}
+		confirmed, err := o.confirmUpgrade(report)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, confirmed, tc.name)
+	}
+
+	// a missing release feed should not block the upgrade
+	o := &CLIOptions{ReleaseFeedURL: filepath.Join(tmpDir, "missing.json"), BatchMode: true}
+	confirmed, err := o.confirmUpgrade(&Report{Channel: ChannelStable, CurrentVersion: "3.1.0", Version: "3.2.0"})
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+
+	// without batch mode or a terminal the upgrade cannot be confirmed
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+	}()
+	o = &CLIOptions{ReleaseFeedURL: feed, Out: &bytes.Buffer{}}
+	confirmed, err = o.confirmUpgrade(&Report{Channel: ChannelStable, CurrentVersion: "3.1.0", Version: "3.1.1"})
+	require.Error(t, err, "should not upgrade without confirmation")
+	assert.Contains(t, err.Error(), "--batch-mode")
+	assert.False(t, confirmed)
+}
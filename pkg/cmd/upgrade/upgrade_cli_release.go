@@ -0,0 +1,169 @@
+package upgrade
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvReleaseBaseURL the environment variable for the base URL of jx releases such as a mirror
+	EnvReleaseBaseURL = "JX_RELEASE_BASE_URL"
+	// EnvReleaseAssetTemplate the environment variable for the template of the release archive names
+	EnvReleaseAssetTemplate = "JX_RELEASE_ASSET_TEMPLATE"
+	// EnvReleaseFeedURL the environment variable for the release feed used by the beta channel and for release notes
+	EnvReleaseFeedURL = "JX_RELEASE_FEED_URL"
+	// EnvVersionStreamURL the environment variable for the version stream used when there is no Kptfile or dev environment
+	EnvVersionStreamURL = "JX_VERSION_STREAM_URL"
+
+	// DefaultReleaseBaseURL the base URL of the jx releases on GitHub
+	DefaultReleaseBaseURL = "https://github.com/jenkins-x/jx/releases/download/v{{.Version}}"
+	// DefaultAssetTemplate the name of the jx release archives
+	DefaultAssetTemplate = "jx-{{.OS}}-{{.Arch}}.{{.Extension}}"
+	// LatestVersionstreamURL the default version stream used when there is no Kptfile or dev environment
+	LatestVersionstreamURL = "https://github.com/jenkins-x/jxr-versions.git"
+)
+
+// ReleaseAsset the values available to the release base URL and asset templates
+type ReleaseAsset struct {
+	// OS the operating system such as `linux`
+	OS string
+	// Arch the architecture such as `amd64`
+	Arch string
+	// Version the version without the `v` prefix such as `3.2.1`
+	Version string
+	// Extension the archive extension: `tar.gz` or `zip` on windows
+	Extension string
+}
+
+// ReleaseSource where the jx releases are downloaded from
+type ReleaseSource struct {
+	// BaseURL the template of the URL of the directory containing the release archive and checksums
+	BaseURL string
+	// AssetTemplate the template of the archive name
+	AssetTemplate string
+	// FeedURL the release feed in the format of the GitHub releases API used by the beta channel and for release notes
+	FeedURL string
+	// VersionStreamURL the version stream used when there is no Kptfile or dev environment
+	VersionStreamURL string
+}
+
+// releaseSource resolves the release source from the flags, then the environment variables, then the configuration
+// falling back to the upstream jx releases
+func (o *CLIOptions) releaseSource() (*ReleaseSource, error) {
+	if o.release != nil {
+		return o.release, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	s := &ReleaseSource{
+		BaseURL:          firstValue(o.ReleaseBaseURL, os.Getenv(EnvReleaseBaseURL), cfg.Upgrade.ReleaseBaseURL, DefaultReleaseBaseURL),
+		AssetTemplate:    firstValue(o.AssetTemplate, os.Getenv(EnvReleaseAssetTemplate), cfg.Upgrade.AssetTemplate, DefaultAssetTemplate),
+		FeedURL:          firstValue(o.ReleaseFeedURL, os.Getenv(EnvReleaseFeedURL), cfg.Upgrade.ReleaseFeedURL, DefaultReleaseFeedURL),
+		VersionStreamURL: firstValue(o.DefaultVersionStreamURL, os.Getenv(EnvVersionStreamURL), cfg.Upgrade.VersionStreamURL, LatestVersionstreamURL),
+	}
+
+	// a base URL without a version uses the layout of GitHub releases
+	if !strings.Contains(s.BaseURL, "{{") {
+		s.BaseURL = strings.TrimSuffix(s.BaseURL, "/") + "/v{{.Version}}"
+	}
+
+	// validate the templates up front so that mistakes are reported before resolving any versions
+	_, err = s.AssetName("0.0.0")
+	if err != nil {
+		return nil, err
+	}
+	_, err = renderTemplate("release base URL", s.BaseURL, newReleaseAsset("0.0.0"))
+	if err != nil {
+		return nil, err
+	}
+	o.release = s
+	return s, nil
+}
+
+// AssetName returns the name of the release archive of the version for the current platform
+func (s *ReleaseSource) AssetName(version string) (string, error) {
+	name, err := renderTemplate("asset template", s.AssetTemplate, newReleaseAsset(version))
+	if err != nil {
+		return "", err
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return "", errors.Errorf("the asset template %s must render a file name but rendered %q", s.AssetTemplate, name)
+	}
+	return name, nil
+}
+
+// DownloadURL returns the URL of the release archive of the version for the current platform
+func (s *ReleaseSource) DownloadURL(version string) (string, error) {
+	name, err := s.AssetName(version)
+	if err != nil {
+		return "", err
+	}
+	baseURL, err := renderTemplate("release base URL", s.BaseURL, newReleaseAsset(version))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + name, nil
+}
+
+func newReleaseAsset(version string) *ReleaseAsset {
+	extension := "tar.gz"
+	if runtime.GOOS == "windows" {
+		extension = "zip"
+	}
+	return &ReleaseAsset{
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Version:   strings.TrimPrefix(version, "v"),
+		Extension: extension,
+	}
+}
+
+func renderTemplate(name, text string, asset *ReleaseAsset) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the %s %s", name, text)
+	}
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, asset)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render the %s %s. The available values are {{.OS}}, {{.Arch}}, {{.Version}} and {{.Extension}}", name, text)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// checkReleaseExists checks the release archive can be downloaded so that a misconfigured mirror is reported clearly
+func checkReleaseExists(u string) error {
+	resp, err := http.Head(u)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check %s", u)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errors.Errorf("no jx release found at %s. Check the release base URL and asset template via --release-base-url and --asset-template, $%s and $%s or the upgrade section of the jx config file", u, EnvReleaseBaseURL, EnvReleaseAssetTemplate)
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		// some servers do not support HEAD requests so leave it to the download to fail
+		return nil
+	case resp.StatusCode >= 400:
+		return errors.Errorf("failed to check %s: status %d", u, resp.StatusCode)
+	}
+	return nil
+}
+
+func firstValue(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
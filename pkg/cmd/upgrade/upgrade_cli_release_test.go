@@ -0,0 +1,118 @@
+package upgrade
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseSource(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", tmpDir)
+	defer os.Unsetenv("JX3_HOME")
+
+	platform := runtime.GOOS + "-" + runtime.GOARCH
+	downloadURL := func(o *CLIOptions) string {
+		u, err := o.DownloadURL("3.2.0")
+		require.NoError(t, err)
+		return u
+	}
+
+	assert.Equal(t, "https://github.com/jenkins-x/jx/releases/download/v3.2.0/jx-"+platform+".tar.gz", downloadURL(&CLIOptions{}), "the upstream releases by default")
+
+	cfg := &config.Config{Upgrade: config.UpgradeConfig{
+		ReleaseBaseURL:   "https://config.example.com/jx/",
+		AssetTemplate:    "myjx_{{.Version}}_{{.OS}}_{{.Arch}}.{{.Extension}}",
+		VersionStreamURL: "https://config.example.com/versions.git",
+	}}
+	require.NoError(t, cfg.Save())
+	assert.Equal(t, "https://config.example.com/jx/v3.2.0/myjx_3.2.0_"+runtime.GOOS+"_"+runtime.GOARCH+".tar.gz", downloadURL(&CLIOptions{}), "a base URL without a template should use the GitHub layout")
+
+	os.Setenv(EnvReleaseBaseURL, "https://env.example.com/{{.Version}}")
+	defer os.Unsetenv(EnvReleaseBaseURL)
+	assert.Equal(t, "https://env.example.com/3.2.0/myjx_3.2.0_"+runtime.GOOS+"_"+runtime.GOARCH+".tar.gz", downloadURL(&CLIOptions{}), "the environment should override the config")
+
+	o := &CLIOptions{
+		ReleaseBaseURL: "https://flag.example.com/releases/{{.Version}}/{{.OS}}",
+		AssetTemplate:  "jx.{{.Extension}}",
+	}
+	assert.Equal(t, "https://flag.example.com/releases/3.2.0/"+runtime.GOOS+"/jx.tar.gz", downloadURL(o), "the flags should override the environment")
+
+	source, err := (&CLIOptions{}).releaseSource()
+	require.NoError(t, err)
+	assert.Equal(t, "https://config.example.com/versions.git", source.VersionStreamURL)
+
+	for _, tmpl := range []string{"jx-{{.Platform}}.tar.gz", "jx-{{.OS", "{{.OS}}/jx"} {
+		_, err = (&CLIOptions{AssetTemplate: tmpl}).DownloadURL("3.2.0")
+		assert.Error(t, err, "template %s should be invalid", tmpl)
+	}
+}
+
+func TestResolveReportDefaultVersionStream(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	versionStreamDir := filepath.Join(tmpDir, "versions")
+	createVersionStream(t, versionStreamDir, "3.2.0")
+	os.Setenv(EnvVersionStreamURL, versionStreamDir)
+	defer os.Unsetenv(EnvVersionStreamURL)
+
+	version.Map["version"] = "3.1.0"
+	defer delete(version.Map, "version")
+
+	o := &CLIOptions{DryRun: true}
+	report, err := o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, SourceDefault, report.VersionStreamSource)
+	assert.Equal(t, versionStreamDir, report.VersionStreamURL)
+	assert.Equal(t, "3.2.0", report.Version)
+}
+
+func TestInstallJxMissingRelease(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	version.Map["version"] = "3.1.0"
+	defer delete(version.Map, "version")
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.Method+" "+path.Base(r.URL.Path))
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	exe := filepath.Join(tmpDir, "bin", BinaryWithExtension("jx"))
+	require.NoError(t, os.MkdirAll(filepath.Dir(exe), 0755))
+	require.NoError(t, ioutil.WriteFile(exe, []byte("old jx"), 0755))
+
+	o := &CLIOptions{
+		Executable:     exe,
+		ReleaseBaseURL: server.URL + "/mirror/{{.Version}}",
+		AssetTemplate:  "jx-{{.OS}}.{{.Extension}}",
+	}
+	err = o.InstallJx(true, "3.2.0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no jx release found at "+server.URL+"/mirror/3.2.0/jx-"+runtime.GOOS+".")
+	assert.Len(t, requested, 1, "should only check the release exists")
+	assert.Contains(t, requested[0], "HEAD ")
+	assertFileContents(t, exe, "old jx")
+}
@@ -0,0 +1,102 @@
+package upgrade
+
+import (
+	"io"
+	"os"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/jenkins-x/jx/pkg/output"
+)
+
+const (
+	// SourceVersionFlag the version was specified via the --version flag so no version stream was used
+	SourceVersionFlag = "version-flag"
+	// SourceFlag the version stream was specified via the --version-stream-git-url flag
+	SourceFlag = "flag"
+	// SourceKptfile the version stream was found in the local versionStream/Kptfile
+	SourceKptfile = "kptfile"
+	// SourceEnvironment the version stream is the source of the dev Environment
+	SourceEnvironment = "environment"
+	// SourceDefault the version stream is the latest upstream version stream
+	SourceDefault = "default"
+	// SourceReleaseFeed the version was resolved from the release feed of the beta or nightly channel
+	SourceReleaseFeed = "release-feed"
+
+	// DecisionUpgrade the resolved version will be installed
+	DecisionUpgrade = "upgrade"
+	// DecisionDowngrade the resolved version is older than the current version and will be installed
+	DecisionDowngrade = "downgrade"
+	// DecisionNoOp the current version will be kept
+	DecisionNoOp = "no-op"
+)
+
+// Report describes how the version of jx to upgrade to was resolved
+type Report struct {
+	// Channel the release channel
+	Channel string `json:"channel"`
+	// VersionStreamSource how the version stream was chosen such as flag, kptfile, environment or default
+	VersionStreamSource string `json:"versionStreamSource"`
+	// VersionStreamReason why the version stream source was chosen
+	VersionStreamReason string `json:"versionStreamReason"`
+	// VersionStreamURL the git URL of the version stream
+	VersionStreamURL string `json:"versionStreamURL,omitempty"`
+	// VersionStreamRef the git ref of the version stream which is empty for the default branch
+	VersionStreamRef string `json:"versionStreamRef,omitempty"`
+	// VersionStreamDirectory the directory of the version stream inside the git repository
+	VersionStreamDirectory string `json:"versionStreamDirectory,omitempty"`
+	// Version the resolved version to install
+	Version string `json:"version"`
+	// CurrentVersion the current version of jx
+	CurrentVersion string `json:"currentVersion"`
+	// Decision whether jx will be upgraded, downgraded or left alone
+	Decision string `json:"decision"`
+	// Reason the reason for the decision
+	Reason string `json:"reason,omitempty"`
+	// DownloadURL the URL the resolved version is downloaded from
+	DownloadURL string `json:"downloadURL"`
+	// InstallMethod how jx was installed such as binary, brew or asdf which determines how it is upgraded
+	InstallMethod string `json:"installMethod,omitempty"`
+	// Instructions what the user needs to do to complete the upgrade via the package manager
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// out returns the writer to print output to which defaults to stdout
+func (o *CLIOptions) out() io.Writer {
+	if o.Out == nil {
+		return os.Stdout
+	}
+	return o.Out
+}
+
+// printReport prints the report as a table or in the requested output format
+func (o *CLIOptions) printReport(r *Report) error {
+	if o.Output != nil {
+		result, err := output.NewResult("UpgradeReport", []string{"channel", "versionStreamSource", "version", "currentVersion", "decision"}, r)
+		if err != nil {
+			return err
+		}
+		return output.Render(o.out(), o.Output, []output.Result{*result})
+	}
+
+	ref := r.VersionStreamRef
+	if ref == "" && r.VersionStreamURL != "" {
+		ref = "default branch"
+	}
+	t := table.CreateTable(o.out())
+	t.AddRow("Channel:", r.Channel)
+	t.AddRow("Version stream source:", r.VersionStreamSource)
+	t.AddRow("Reason:", r.VersionStreamReason)
+	t.AddRow("Version stream URL:", r.VersionStreamURL)
+	t.AddRow("Version stream ref:", ref)
+	t.AddRow("Version stream directory:", r.VersionStreamDirectory)
+	t.AddRow("Resolved version:", r.Version)
+	t.AddRow("Current version:", r.CurrentVersion)
+	t.AddRow("Decision:", r.Decision+" ("+r.Reason+")")
+	t.AddRow("Download URL:", r.DownloadURL)
+	t.AddRow("Install method:", r.InstallMethod)
+	if r.Instructions != "" {
+		t.AddRow("Instructions:", r.Instructions)
+	}
+	t.Render()
+	return nil
+}
@@ -0,0 +1,242 @@
+package upgrade
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitClient fetches a version stream by copying a local directory recording the fetched refs
+type fakeGitClient struct {
+	dir     string
+	fetched []string
+}
+
+func (g *fakeGitClient) Command(dir string, args ...string) (string, error) {
+	switch args[0] {
+	case "init":
+		return "", os.MkdirAll(filepath.Join(args[1], ".git"), files.DefaultDirWritePermissions)
+	case "fetch":
+		g.fetched = append(g.fetched, args[len(args)-1])
+	case "reset":
+		return "", files.CopyDirOverwrite(g.dir, dir)
+	}
+	return "", nil
+}
+
+func createVersionStream(t *testing.T, dir, jxVersion string) {
+	packagesDir := filepath.Join(dir, "packages")
+	require.NoError(t, os.MkdirAll(packagesDir, files.DefaultDirWritePermissions))
+	err := ioutil.WriteFile(filepath.Join(packagesDir, "jx.yml"), []byte("version: "+jxVersion+"\n"), files.DefaultFileWritePermissions)
+	require.NoError(t, err)
+}
+
+// isolateHome uses a jx home dir in the given directory and clears the release environment variables so that
+// the developer's own configuration does not change the result returning the function to restore them
+func isolateHome(dir string) func() {
+	envVars := []string{"JX3_HOME", EnvReleaseBaseURL, EnvReleaseAssetTemplate, EnvReleaseFeedURL, EnvVersionStreamURL}
+	old := map[string]string{}
+	for _, e := range envVars {
+		if v, ok := os.LookupEnv(e); ok {
+			old[e] = v
+		}
+		os.Unsetenv(e)
+	}
+	os.Setenv("JX3_HOME", filepath.Join(dir, "home"))
+	return func() {
+		for _, e := range envVars {
+			if v, ok := old[e]; ok {
+				os.Setenv(e, v)
+			} else {
+				os.Unsetenv(e)
+			}
+		}
+	}
+}
+
+func TestResolveReport(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	defer isolateHome(tmpDir)()
+
+	versionStreamDir := filepath.Join(tmpDir, "versions")
+	createVersionStream(t, versionStreamDir, "3.2.0")
+
+	testCases := []struct {
+		current          string
+		expectedDecision string
+		expectedReason   string
+	}{
+		{current: "3.1.0", expectedDecision: DecisionUpgrade, expectedReason: "newer"},
+		{current: "3.2.0", expectedDecision: DecisionNoOp, expectedReason: "already"},
+		{current: "3.3.0", expectedDecision: DecisionNoOp, expectedReason: "--allow-downgrade"},
+		{current: "3.1.0-dev+6a8285f4", expectedDecision: DecisionNoOp, expectedReason: "dev build"},
+	}
+	defer delete(version.Map, "version")
+	for _, tc := range testCases {
+		version.Map["version"] = tc.current
+		o := &CLIOptions{
+			VersionStreamGitURL: versionStreamDir,
+			DryRun:              true,
+		}
+		report, err := o.Resolve()
+		require.NoError(t, err, "for current version %s", tc.current)
+
+		assert.Equal(t, SourceFlag, report.VersionStreamSource, "source for %s", tc.current)
+		assert.Equal(t, versionStreamDir, report.VersionStreamURL, "URL for %s", tc.current)
+		assert.Equal(t, "3.2.0", report.Version, "version for %s", tc.current)
+		assert.Equal(t, tc.current, report.CurrentVersion, "current version")
+		assert.Equal(t, tc.expectedDecision, report.Decision, "decision for %s", tc.current)
+		assert.Contains(t, report.Reason, tc.expectedReason, "reason for %s", tc.current)
+		downloadURL, err := o.DownloadURL("3.2.0")
+		require.NoError(t, err)
+		assert.Equal(t, downloadURL, report.DownloadURL, "download URL for %s", tc.current)
+	}
+}
+
+func TestResolveReportFromKptfile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	defer isolateHome(tmpDir)()
+
+	versionStreamDir := filepath.Join(tmpDir, "versions")
+	createVersionStream(t, filepath.Join(versionStreamDir, "versionStream"), "3.2.0")
+
+	clusterDir := filepath.Join(tmpDir, "cluster")
+	require.NoError(t, os.MkdirAll(filepath.Join(clusterDir, "versionStream"), files.DefaultDirWritePermissions))
+	err = ioutil.WriteFile(filepath.Join(clusterDir, "versionStream", "Kptfile"), []byte(`apiVersion: kpt.dev/v1alpha1
+kind: Kptfile
+upstream:
+  type: git
+  git:
+    repo: `+versionStreamDir+`
+    directory: /versionStream
+    ref: master
+`), files.DefaultFileWritePermissions)
+	require.NoError(t, err)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(clusterDir))
+	defer os.Chdir(wd)
+
+	version.Map["version"] = "3.1.0"
+	defer delete(version.Map, "version")
+
+	o := &CLIOptions{
+		DryRun: true,
+	}
+	report, err := o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, SourceKptfile, report.VersionStreamSource)
+	assert.Equal(t, versionStreamDir, report.VersionStreamURL)
+	assert.Equal(t, "3.2.0", report.Version)
+	assert.Equal(t, DecisionUpgrade, report.Decision)
+
+	// dry run should not try to download anything
+	o.Version = ""
+	require.NoError(t, o.Run())
+}
+
+func TestResolveReportFromLockedKptfile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	versionStreamDir := filepath.Join(tmpDir, "versions")
+	createVersionStream(t, filepath.Join(versionStreamDir, "stream"), "3.2.0")
+	// the version stream at the root of the repository should be ignored
+	createVersionStream(t, versionStreamDir, "9.9.9")
+
+	clusterDir := filepath.Join(tmpDir, "cluster")
+	require.NoError(t, os.MkdirAll(filepath.Join(clusterDir, "versionStream"), files.DefaultDirWritePermissions))
+	err = ioutil.WriteFile(filepath.Join(clusterDir, "versionStream", "Kptfile"), []byte(`apiVersion: kpt.dev/v1
+kind: Kptfile
+upstream:
+  type: git
+  git:
+    repo: https://github.com/jenkins-x/jxr-versions.git
+    directory: /stream
+    ref: master
+upstreamLock:
+  type: git
+  git:
+    repo: https://github.com/jenkins-x/jxr-versions.git
+    directory: /stream
+    ref: master
+    commit: 0a1b2c3d
+`), files.DefaultFileWritePermissions)
+	require.NoError(t, err)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(clusterDir))
+	defer os.Chdir(wd)
+
+	version.Map["version"] = "3.1.0"
+	defer delete(version.Map, "version")
+
+	g := &fakeGitClient{dir: versionStreamDir}
+	o := &CLIOptions{
+		GitClient: g,
+		DryRun:    true,
+	}
+	report, err := o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, SourceKptfile, report.VersionStreamSource)
+	assert.Equal(t, "0a1b2c3d", report.VersionStreamRef, "should use the locked commit")
+	assert.Equal(t, "/stream", report.VersionStreamDirectory)
+	assert.Equal(t, "3.2.0", report.Version)
+	assert.Equal(t, []string{"0a1b2c3d"}, g.fetched)
+
+	o = &CLIOptions{
+		GitClient:        g,
+		VersionStreamRef: "v1.2.3",
+		DryRun:           true,
+	}
+	report, err = o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", report.VersionStreamRef, "the flag should override the Kptfile")
+	assert.Equal(t, []string{"0a1b2c3d", "v1.2.3"}, g.fetched)
+}
+
+func TestResolveReportWithVersion(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	defer isolateHome(tmpDir)()
+
+	version.Map["version"] = "3.1.0"
+	defer delete(version.Map, "version")
+
+	o := &CLIOptions{
+		Version: "3.1.5",
+		DryRun:  true,
+	}
+	report, err := o.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, SourceVersionFlag, report.VersionStreamSource)
+	assert.Empty(t, report.VersionStreamURL)
+	assert.Equal(t, DecisionUpgrade, report.Decision)
+	assert.Equal(t, "https://github.com/jenkins-x/jx/releases/download/v3.1.5/jx-"+runtime.GOOS+"-"+runtime.GOARCH+".tar.gz", report.DownloadURL)
+
+	out := &bytes.Buffer{}
+	o.Out = out
+	o.Output, err = output.ParseFormat("jsonpath={[*].version}")
+	require.NoError(t, err)
+	require.NoError(t, o.Run())
+	assert.Equal(t, "3.1.5\n", out.String(), "the report should be rendered to the output writer")
+}
@@ -0,0 +1,211 @@
+package upgrade
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/inconshreveable/go-update"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/rhysd/go-github-selfupdate/selfupdate"
+)
+
+const (
+	// ChecksumsFileName the name of the file published with each jx release containing the SHA-256 checksums of the archives
+	ChecksumsFileName = "jx-checksums.txt"
+	// SignatureFileSuffix the suffix of the base64 encoded signature of the checksums file published alongside it
+	SignatureFileSuffix = ".sig"
+)
+
+// downloadVerifiedArchive downloads the release archive into the directory verifying it against the published
+// checksums and the signature of the checksums if there is one. Returns the path of the archive
+func (o *CLIOptions) downloadVerifiedArchive(clientURL, dir string) (string, error) {
+	data, found, err := download(clientURL)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", errors.Errorf("failed to download %s: not found", clientURL)
+	}
+	archiveName := path.Base(clientURL)
+	archive := filepath.Join(dir, archiveName)
+	err = ioutil.WriteFile(archive, data, 0600)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to save %s", archive)
+	}
+
+	if o.SkipVerify {
+		log.Logger().Warnf("skipping the verification of %s", clientURL)
+		return archive, nil
+	}
+
+	checksumsURL := releaseFileURL(clientURL, ChecksumsFileName)
+	checksums, found, err := download(checksumsURL)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", errors.Errorf("no checksums published at %s so %s cannot be verified. Use --skip-verify to upgrade anyway", checksumsURL, archiveName)
+	}
+
+	err = o.verifySignature(checksumsURL, checksums)
+	if err != nil {
+		return "", err
+	}
+
+	expected, err := findChecksum(checksums, archiveName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the checksum of %s in %s", archiveName, checksumsURL)
+	}
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(expected, actual) {
+		return "", errors.Errorf("checksum mismatch for %s: expected %s but downloaded %s", archiveName, expected, actual)
+	}
+	log.Logger().Debugf("verified the checksum of %s", archiveName)
+	return archive, nil
+}
+
+// verifySignature verifies the signature of the checksums file. The signature is required if a public key is
+// configured and a published signature cannot be ignored without --skip-verify
+func (o *CLIOptions) verifySignature(checksumsURL string, checksums []byte) error {
+	signatureURL := checksumsURL + SignatureFileSuffix
+	signature, found, err := download(signatureURL)
+	if err != nil {
+		if o.PublicKey != "" {
+			return errors.Wrapf(err, "failed to download the signature required by --public-key")
+		}
+		return err
+	}
+	if !found {
+		if o.PublicKey != "" {
+			return errors.Errorf("no signature published at %s but one is required by --public-key %s", signatureURL, o.PublicKey)
+		}
+		log.Logger().Debugf("no signature published at %s", signatureURL)
+		return nil
+	}
+	if o.PublicKey == "" {
+		return errors.Errorf("a signature is published at %s but it cannot be verified without --public-key. Use --skip-verify to upgrade anyway", signatureURL)
+	}
+	keyData, err := ioutil.ReadFile(o.PublicKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read public key %s", o.PublicKey)
+	}
+	err = VerifySignature(keyData, checksums, signature)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify %s", signatureURL)
+	}
+	log.Logger().Debugf("verified the signature %s", signatureURL)
+	return nil
+}
+
+// VerifySignature verifies the base64 encoded signature of the data using the PEM encoded ECDSA or Ed25519 public key.
+// ECDSA signatures are ASN.1 encoded signatures of the SHA-256 digest of the data as created by `cosign sign-blob`
+func VerifySignature(publicKeyPEM, data, signature []byte) error {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return errors.New("the public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.Wrap(err, "failed to decode the base64 signature")
+	}
+
+	valid := false
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = ecdsa.VerifyASN1(k, digest[:], sig)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, data, sig)
+	default:
+		return errors.Errorf("unsupported public key type %T", key)
+	}
+	if !valid {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// findChecksum finds the checksum of the file in the checksums file which has lines of the form `<sha256>  <file>`
+func findChecksum(checksums []byte, fileName string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == fileName {
+			return fields[0], nil
+		}
+	}
+	return "", errors.Errorf("no checksum for %s", fileName)
+}
+
+// releaseFileURL returns the URL of another file in the same release as the archive URL
+func releaseFileURL(archiveURL, name string) string {
+	idx := strings.LastIndex(archiveURL, "/")
+	if idx < 0 {
+		return name
+	}
+	return archiveURL[:idx+1] + name
+}
+
+// download downloads the URL returning false if it is not found
+func download(u string) ([]byte, bool, error) {
+	data, _, found, err := downloadWithHeader(u)
+	return data, found, err
+}
+
+// downloadWithHeader downloads the URL returning the response header and false if it is not found
+func downloadWithHeader(u string) ([]byte, http.Header, bool, error) {
+	resp, err := http.Get(u)
+	if err != nil {
+		return nil, nil, false, errors.Wrapf(err, "failed to download %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, resp.Header, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.Header, false, errors.Errorf("failed to download %s: status %d", u, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, false, errors.Wrapf(err, "failed to read %s", u)
+	}
+	return data, resp.Header, true, nil
+}
+
+// applyArchive replaces the executable with the jx binary inside the archive
+func applyArchive(archive, archiveURL, exe string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", archive)
+	}
+	defer f.Close()
+
+	binary, err := selfupdate.UncompressCommand(f, archiveURL, filepath.Base(exe))
+	if err != nil {
+		return errors.Wrapf(err, "failed to extract %s from %s", filepath.Base(exe), archiveURL)
+	}
+	err = update.Apply(binary, update.Options{TargetPath: exe})
+	if err != nil {
+		return errors.Wrapf(err, "failed to replace %s", exe)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createArchive creates a release archive containing a jx binary with the given contents
+func createArchive(t *testing.T, contents string) []byte {
+	buf := &bytes.Buffer{}
+	if runtime.GOOS == "windows" {
+		w := zip.NewWriter(buf)
+		f, err := w.Create("jx.exe")
+		require.NoError(t, err)
+		_, err = f.Write([]byte(contents))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "jx", Mode: 0755, Size: int64(len(contents))}))
+	_, err := tw.Write([]byte(contents))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestInstallJxVerifiesRelease(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-upgrade-cli-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", filepath.Join(tmpDir, "home"))
+	defer os.Unsetenv("JX3_HOME")
+
+	version.Map["version"] = "3.1.0"
+	defer delete(version.Map, "version")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyData, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicKey := filepath.Join(tmpDir, "cosign.pub")
+	err = ioutil.WriteFile(publicKey, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyData}), 0600)
+	require.NoError(t, err)
+
+	// the files served by the release stand in indexed by file name
+	release := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := release[path.Base(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	o := &CLIOptions{
+		ReleaseBaseURL: server.URL + "/v{{.Version}}",
+		PublicKey:      publicKey,
+	}
+	downloadURL, err := o.DownloadURL("3.2.0")
+	require.NoError(t, err)
+	archiveName := path.Base(downloadURL)
+	archive := createArchive(t, "new jx")
+	sum := sha256.Sum256(archive)
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  " + archiveName + "\n")
+	digest := sha256.Sum256(checksums)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	signature := []byte(base64.StdEncoding.EncodeToString(sig))
+
+	testCases := []struct {
+		name     string
+		files    map[string][]byte
+		noKey    bool
+		expected string
+	}{
+		{
+			name:     "missing checksums",
+			files:    map[string][]byte{archiveName: archive},
+			expected: "no checksums published",
+		},
+		{
+			name: "tampered archive",
+			files: map[string][]byte{
+				archiveName:                             createArchive(t, "evil jx"),
+				ChecksumsFileName:                       checksums,
+				ChecksumsFileName + SignatureFileSuffix: signature,
+			},
+			expected: "checksum mismatch",
+		},
+		{
+			name: "tampered checksums",
+			files: map[string][]byte{
+				archiveName:                             archive,
+				ChecksumsFileName:                       append(checksums, []byte("0000  other.tar.gz\n")...),
+				ChecksumsFileName + SignatureFileSuffix: signature,
+			},
+			expected: "invalid signature",
+		},
+		{
+			name: "key set, signature missing",
+			files: map[string][]byte{
+				archiveName:       archive,
+				ChecksumsFileName: checksums,
+			},
+			expected: "but one is required by --public-key",
+		},
+		{
+			name: "signature published but no key",
+			files: map[string][]byte{
+				archiveName:                             archive,
+				ChecksumsFileName:                       checksums,
+				ChecksumsFileName + SignatureFileSuffix: signature,
+			},
+			noKey:    true,
+			expected: "cannot be verified without --public-key",
+		},
+		{
+			name: "valid release",
+			files: map[string][]byte{
+				archiveName:                             archive,
+				ChecksumsFileName:                       checksums,
+				ChecksumsFileName + SignatureFileSuffix: signature,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		exe := filepath.Join(tmpDir, "bin", BinaryWithExtension("jx"))
+		require.NoError(t, os.MkdirAll(filepath.Dir(exe), 0755))
+		require.NoError(t, ioutil.WriteFile(exe, []byte("old jx"), 0755))
+		o.Executable = exe
+		o.PublicKey = publicKey
+		if tc.noKey {
+			o.PublicKey = ""
+		}
+		release = tc.files
+
+		err = o.InstallJx(true, "3.2.0")
+		if tc.expected != "" {
+			require.Error(t, err, tc.name)
+			assert.Contains(t, err.Error(), tc.expected, tc.name)
+			assertFileContents(t, exe, "old jx")
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assertFileContents(t, exe, "new jx")
+
+		h, err := LoadHistory()
+		require.NoError(t, err)
+		require.Len(t, h.Entries, 1, "only the valid release should be recorded")
+		assert.Equal(t, "3.1.0", h.Entries[0].From)
+		assert.Equal(t, "3.2.0", h.Entries[0].To)
+	}
+}
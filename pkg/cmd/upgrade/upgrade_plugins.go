@@ -4,11 +4,12 @@ import (
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
 	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/jenkins-x/jx/pkg/plugins/policy"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -23,12 +24,7 @@ var (
 		jx upgrade plugins
 	`)
 
-	bootPlugins = map[string]bool{
-		"gitops": true,
-		"health": true,
-		"secret": true,
-		"verify": true,
-	}
+	bootPlugins = plugins.DefaultCatalog.BootPlugins()
 )
 
 // UpgradeOptions the options for upgrading a cluster
@@ -70,16 +66,40 @@ func (o *PluginOptions) Run() error {
 	if o.CommandRunner == nil {
 		o.CommandRunner = cmdrunner.DefaultCommandRunner
 	}
-	for k := range plugins.Plugins {
-		p := plugins.Plugins[k]
+	policies, err := policy.Load()
+	if err != nil {
+		return errors.Wrap(err, "failed to load plugin policies")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return errors.Wrap(err, "failed to load jx config")
+	}
+	catalog, err := plugins.CatalogPlugins(cfg)
+	if err != nil {
+		return err
+	}
+	for k := range catalog {
+		p := catalog[k]
 		if o.Boot && !bootPlugins[p.Name] {
 			continue
 		}
-		if o.OnlyMandatory && p.Name == "jenkins" {
+		if o.OnlyMandatory {
+			entry := plugins.DefaultCatalog.Lookup(p.Spec.Name)
+			if entry == nil || !entry.Mandatory {
+				continue
+			}
+		}
+		err = policies.Verify(policy.Plugin{
+			Name:    p.Spec.Name,
+			Version: p.Spec.Version,
+			Source:  plugins.PluginSource(&p),
+		})
+		if err != nil {
+			log.Logger().Warnf("skipping plugin: %s", err.Error())
 			continue
 		}
 		log.Logger().Infof("checking binary jx plugin %s version %s is installed", termcolor.ColorInfo(p.Name), termcolor.ColorInfo(p.Spec.Version))
-		fileName, err := extensions.EnsurePluginInstalled(p, pluginBinDir)
+		fileName, err := plugins.EnsurePluginInstalled(p, pluginBinDir)
 		if err != nil {
 			return errors.Wrapf(err, "failed to ensure plugin is installed %s", p.Name)
 		}
@@ -1,9 +1,13 @@
 package version
 
 import (
+	"io"
+	"os"
+
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/output"
 	"github.com/spf13/cobra"
 )
 
@@ -25,10 +29,21 @@ const (
 	TestVersion = "1.0.0-SNAPSHOT"
 )
 
+// Info the structured version information
+type Info struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"`
+}
+
 // ShowOptions the options for viewing running PRs
 type Options struct {
 	Verbose bool
 	Quiet   bool
+	Output  *output.Format
+	Out     io.Writer
 }
 
 // NewCmdVersion creates a command object for the "version" command
@@ -39,7 +54,11 @@ func NewCmdVersion() (*cobra.Command, *Options) {
 		Use:   "version",
 		Short: "Displays the version of this command",
 		Run: func(cmd *cobra.Command, args []string) {
-			err := o.Run()
+			var err error
+			o.Output, err = output.FormatFromCommand(cmd)
+			helper.CheckErr(err)
+			o.Out = cmd.OutOrStdout()
+			err = o.Run()
 			helper.CheckErr(err)
 		},
 	}
@@ -50,6 +69,16 @@ func NewCmdVersion() (*cobra.Command, *Options) {
 // Run implements the command
 func (o *Options) Run() error {
 	v := GetVersion()
+	if o.Output != nil {
+		r, err := output.NewResult("Version", []string{"version", "revision", "buildDate", "goVersion"}, GetInfo())
+		if err != nil {
+			return err
+		}
+		if o.Out == nil {
+			o.Out = os.Stdout
+		}
+		return output.Render(o.Out, o.Output, []output.Result{*r})
+	}
 	if o.Quiet {
 		log.Logger().Infof(v)
 		return nil
@@ -64,3 +93,14 @@ func GetVersion() string {
 	}
 	return TestVersion
 }
+
+// GetInfo returns the structured version information
+func GetInfo() *Info {
+	return &Info{
+		Version:   GetVersion(),
+		Revision:  Revision,
+		Branch:    Branch,
+		BuildDate: BuildDate,
+		GoVersion: GoVersion,
+	}
+}
@@ -0,0 +1,111 @@
+package which
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Displays which built-in command or plugin executable is used for a command.
+
+		Plugins are resolved using the longest matching name made from jx- followed by the command words such as jx-release-notes. Any version requested with --plugin-version is used first. Otherwise each name is checked in this order:
+
+		* plugins linked to a local build
+		* plugins on the PATH
+		* the default plugins in the catalog
+		* project plugins in a .jx/plugins directory
+		* community plugins which were previously installed
+
+		If none of the names match then jx looks up and installs a community plugin for each name. This command does not do that lookup as it never installs anything.
+
+		Project plugins are run without asking you to trust them so only run jx in repositories you trust.
+`)
+
+	cmdExample = templates.Examples(`
+		# view which plugin is used for jx gitops
+		jx which gitops
+
+		# view which project plugin is used for jx release notes
+		jx which release notes
+	`)
+
+	info = termcolor.ColorInfo
+)
+
+// Resolver resolves the plugin for the given command line arguments without installing it.
+// Returns nil if there is no plugin
+type Resolver func(args []string) (*plugins.Resolution, error)
+
+// Options the options for the command
+type Options struct {
+	Resolver Resolver
+	Cmd      *cobra.Command
+	Args     []string
+}
+
+// NewCmdWhich creates a command object for the command
+func NewCmdWhich(resolver Resolver) (*cobra.Command, *Options) {
+	o := &Options{
+		Resolver: resolver,
+	}
+
+	o.Cmd = &cobra.Command{
+		Use:     "which COMMAND...",
+		Short:   "Displays which built-in command or plugin is used for a command",
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return o.Cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	if len(o.Args) == 0 {
+		return errors.Errorf("missing command argument")
+	}
+	if o.Cmd != nil {
+		c, _, err := o.Cmd.Root().Find(o.Args)
+		if err == nil && c != nil && c != o.Cmd.Root() {
+			log.Logger().Infof("jx %s is a built-in command", info(strings.TrimPrefix(c.CommandPath(), "jx ")))
+			return nil
+		}
+	}
+	if o.Resolver == nil {
+		return errors.Errorf("no plugin resolver")
+	}
+	r, err := o.Resolver(o.Args)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve plugin for jx %s", strings.Join(o.Args, " "))
+	}
+	if r == nil {
+		return errors.Errorf("no command or plugin found for jx %s", strings.Join(o.Args, " "))
+	}
+
+	log.Logger().Infof("%s", r.Path)
+	switch r.Kind {
+	case plugins.ResolutionDefault:
+		log.Logger().Infof("default plugin %s version %s", info(r.Name), info(r.Version))
+	case plugins.ResolutionLinked:
+		log.Logger().Infof("linked plugin %s (remove with: jx plugin unlink %s)", info(r.Name), strings.TrimPrefix(r.Name, "jx-"))
+	case plugins.ResolutionVersion:
+		log.Logger().Infof("plugin %s version %s", info(r.Name), info(r.Version))
+	case plugins.ResolutionProject:
+		log.Logger().Infof("project plugin %s", info(r.Name))
+	default:
+		log.Logger().Infof("local plugin %s", info(r.Name))
+	}
+	return nil
+}
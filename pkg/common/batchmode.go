@@ -0,0 +1,34 @@
+package common
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvBatchMode the environment variable which enables batch mode so that commands do not prompt
+const EnvBatchMode = "JX_BATCH_MODE"
+
+// IsBatchMode returns true if batch mode is enabled via the environment or command line arguments
+func IsBatchMode(args, environ []string) bool {
+	prefix := EnvBatchMode + "="
+	for _, e := range environ {
+		if strings.HasPrefix(e, prefix) && strings.ToLower(strings.TrimSpace(strings.TrimPrefix(e, prefix))) == "true" {
+			return true
+		}
+	}
+	for _, a := range args {
+		if a == "-b" || a == "--batch-mode" || a == "--batch-mode=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal returns true if the file is a terminal rather than a pipe or regular file
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
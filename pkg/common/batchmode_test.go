@@ -0,0 +1,16 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBatchMode(t *testing.T) {
+	assert.False(t, common.IsBatchMode(nil, nil))
+	assert.False(t, common.IsBatchMode([]string{"upgrade"}, []string{"JX_BATCH_MODE=false"}))
+	assert.True(t, common.IsBatchMode(nil, []string{"JX_BATCH_MODE= TRUE"}))
+	assert.True(t, common.IsBatchMode([]string{"upgrade", "-b"}, nil))
+	assert.True(t, common.IsBatchMode([]string{"--batch-mode=true"}, nil))
+}
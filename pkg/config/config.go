@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ConfigFileName the name of the jx CLI configuration file inside the jx home dir
+	ConfigFileName = "config.yaml"
+
+	// EnvPluginAllowlist the environment variable for a comma separated list of plugins allowed without prompting
+	EnvPluginAllowlist = "JX_PLUGIN_ALLOWLIST"
+)
+
+// Config the local configuration of the jx CLI
+type Config struct {
+	// PluginAllowlist the community plugins which may be installed without a trust prompt (e.g. in batch mode)
+	PluginAllowlist []string `json:"pluginAllowlist,omitempty"`
+
+	// PluginLinks local builds of plugins indexed by the plugin binary name such as `jx-gitops` which
+	// override the managed plugin versions
+	PluginLinks map[string]string `json:"pluginLinks,omitempty"`
+
+	// Plugins additional plugins installed from sources such as OCI registries
+	Plugins []PluginSource `json:"plugins,omitempty"`
+
+	// Audit the audit log of plugin invocations
+	Audit AuditConfig `json:"audit,omitempty"`
+
+	// Hooks executables run before and after commands
+	Hooks []Hook `json:"hooks,omitempty"`
+
+	// Upgrade the configuration of `jx upgrade cli`
+	Upgrade UpgradeConfig `json:"upgrade,omitempty"`
+}
+
+// UpgradeConfig the configuration of how the jx CLI upgrades itself
+type UpgradeConfig struct {
+	// Channel the release channel to upgrade from: stable, beta or nightly. Defaults to stable
+	Channel string `json:"channel,omitempty"`
+	// ReleaseFeedURL the URL of the JSON list of releases used by the beta channel in the format of the GitHub releases API
+	ReleaseFeedURL string `json:"releaseFeedURL,omitempty"`
+	// NightlyFeedURL the URL of the JSON list of nightly builds used by the nightly channel in the format of the GitHub releases API
+	NightlyFeedURL string `json:"nightlyFeedURL,omitempty"`
+	// ReleaseBaseURL the template of the URL the jx releases are downloaded from such as a mirror or fork. The template
+	// can use {{.OS}}, {{.Arch}}, {{.Version}} and {{.Extension}} and defaults to the GitHub releases of jx
+	ReleaseBaseURL string `json:"releaseBaseURL,omitempty"`
+	// AssetTemplate the template of the release archive names. Defaults to `jx-{{.OS}}-{{.Arch}}.{{.Extension}}`
+	AssetTemplate string `json:"assetTemplate,omitempty"`
+	// VersionStreamURL the version stream used when there is no local Kptfile or dev environment
+	VersionStreamURL string `json:"versionStreamURL,omitempty"`
+}
+
+// Hook runs executables before and after the commands matching any of its globs
+type Hook struct {
+	// Name the name of the hook used in log messages
+	Name string `json:"name,omitempty"`
+	// Commands globs of the command paths the hook applies to such as 'promote' or 'admin *'
+	Commands []string `json:"commands"`
+	// Pre the executable and arguments run before the command. A non-zero exit code aborts the command
+	Pre []string `json:"pre,omitempty"`
+	// Post the executable and arguments run after the command
+	Post []string `json:"post,omitempty"`
+}
+
+// AuditConfig the configuration of the audit log of plugin invocations
+type AuditConfig struct {
+	// Enabled enables the audit log
+	Enabled bool `json:"enabled,omitempty"`
+	// Dir the directory to write the audit log to. Defaults to the audit dir in the jx home dir
+	Dir string `json:"dir,omitempty"`
+}
+
+// PluginSource an additional plugin and where it is installed from
+type PluginSource struct {
+	// Name the name of the plugin such as `jx-foo`
+	Name string `json:"name"`
+	// Version the version of the plugin. Defaults to the tag or digest of the source
+	Version string `json:"version,omitempty"`
+	// Source the location of the plugin such as `oci://ghcr.io/myorg/jx-foo:1.2.3`
+	Source string `json:"source"`
+}
+
+// HomeDir returns the jx home dir, `~/.jx3` by default or `$JX3_HOME` if set
+func HomeDir() (string, error) {
+	dir, err := homedir.ConfigDir(os.Getenv("JX3_HOME"), ".jx3")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find jx home dir")
+	}
+	return dir, nil
+}
+
+// ConfigFile returns the location of the jx CLI configuration file
+func ConfigFile() (string, error) {
+	dir, err := HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ConfigFileName), nil
+}
+
+// Load loads the jx CLI configuration returning an empty configuration if there is no file
+func Load() (*Config, error) {
+	path, err := ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile loads the configuration from the given file returning an empty configuration if the file does not exist
+func LoadFile(path string) (*Config, error) {
+	c := &Config{}
+	err := yamls.LoadFile(path, c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load jx config %s", path)
+	}
+	return c, nil
+}
+
+// Save saves the configuration to the default location
+func (c *Config) Save() error {
+	path, err := ConfigFile()
+	if err != nil {
+		return err
+	}
+	return c.SaveFile(path)
+}
+
+// SaveFile saves the configuration to the given file
+func (c *Config) SaveFile(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir for %s", path)
+	}
+	err = yamls.SaveFile(c, path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save jx config %s", path)
+	}
+	return nil
+}
+
+// IsPluginAllowed returns true if the given plugin name such as `jx-foo` is in the allow list
+// either from the configuration or the $JX_PLUGIN_ALLOWLIST environment variable
+func (c *Config) IsPluginAllowed(name string) bool {
+	allowed := append([]string{}, c.PluginAllowlist...)
+	if env := os.Getenv(EnvPluginAllowlist); env != "" {
+		allowed = append(allowed, strings.Split(env, ",")...)
+	}
+	shortName := strings.TrimPrefix(name, "jx-")
+	for _, a := range allowed {
+		a = strings.TrimSpace(a)
+		if a == name || a == shortName {
+			return true
+		}
+	}
+	return false
+}
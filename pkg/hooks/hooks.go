@@ -0,0 +1,172 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvHookPhase the environment variable for the phase of the hook: 'pre' or 'post'
+	EnvHookPhase = "JX_HOOK_PHASE"
+	// EnvHookCommand the environment variable for the command path the hook is running for such as 'admin operator'
+	EnvHookCommand = "JX_HOOK_COMMAND"
+	// EnvHookArgs the environment variable for the space separated command line arguments
+	EnvHookArgs = "JX_HOOK_ARGS"
+	// EnvHookExitCode the environment variable for the exit code of the command passed to post hooks
+	EnvHookExitCode = "JX_HOOK_EXIT_CODE"
+	// EnvHookDurationMillis the environment variable for how long the command ran for in milliseconds passed to post hooks
+	EnvHookDurationMillis = "JX_HOOK_DURATION_MILLIS"
+
+	// PhasePre the phase before the command runs
+	PhasePre = "pre"
+	// PhasePost the phase after the command runs
+	PhasePost = "post"
+)
+
+// Runner runs the hooks matching commands
+type Runner struct {
+	Hooks   []config.Hook
+	Environ []string
+	In      io.Reader
+	Out     io.Writer
+	Err     io.Writer
+}
+
+// CommandPath returns the command path words from the leading arguments which are not flags
+func CommandPath(args []string) []string {
+	var answer []string
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") {
+			break
+		}
+		answer = append(answer, a)
+	}
+	return answer
+}
+
+// Matches returns true if any of the hook globs matches the command path or one of its prefixes
+// so that 'promote' matches 'jx promote myapp'
+func Matches(hook *config.Hook, commandPath []string) bool {
+	for i := len(commandPath); i > 0; i-- {
+		text := strings.Join(commandPath[:i], " ")
+		for _, glob := range hook.Commands {
+			matched, err := path.Match(glob, text)
+			if err == nil && matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasHooks returns true if there are any hooks for the command path in the given phase
+func (r *Runner) HasHooks(phase string, commandPath []string) bool {
+	if r == nil {
+		return false
+	}
+	for i := range r.Hooks {
+		if len(r.command(&r.Hooks[i], phase)) > 0 && Matches(&r.Hooks[i], commandPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pre runs the pre hooks for the command returning an error if any of them fail so that the command is aborted
+func (r *Runner) Pre(commandPath, args []string) error {
+	if r == nil {
+		return nil
+	}
+	for i := range r.Hooks {
+		hook := &r.Hooks[i]
+		if len(hook.Pre) == 0 || !Matches(hook, commandPath) {
+			continue
+		}
+		err := r.run(hook, PhasePre, commandPath, args, nil)
+		if err != nil {
+			return errors.Wrapf(err, "aborting jx %s as the pre hook %s failed", strings.Join(commandPath, " "), hookName(hook))
+		}
+	}
+	return nil
+}
+
+// Post runs the post hooks for the command with its exit code and duration logging any failures
+func (r *Runner) Post(commandPath, args []string, exitCode int, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	for i := range r.Hooks {
+		hook := &r.Hooks[i]
+		if len(hook.Post) == 0 || !Matches(hook, commandPath) {
+			continue
+		}
+		env := []string{
+			fmt.Sprintf("%s=%d", EnvHookExitCode, exitCode),
+			fmt.Sprintf("%s=%s", EnvHookDurationMillis, strconv.FormatInt(duration.Milliseconds(), 10)),
+		}
+		err := r.run(hook, PhasePost, commandPath, args, env)
+		if err != nil {
+			log.Logger().Warnf("the post hook %s failed: %s", hookName(hook), err.Error())
+		}
+	}
+}
+
+func (r *Runner) command(hook *config.Hook, phase string) []string {
+	if phase == PhasePre {
+		return hook.Pre
+	}
+	return hook.Post
+}
+
+func (r *Runner) run(hook *config.Hook, phase string, commandPath, args, env []string) error {
+	command := r.command(hook, phase)
+	log.Logger().Debugf("running %s hook %s: %s", phase, hookName(hook), strings.Join(command, " "))
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = r.In
+	cmd.Stdout = r.Out
+	cmd.Stderr = r.Err
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	environ := r.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	cmd.Env = append(append([]string{}, environ...),
+		fmt.Sprintf("%s=%s", EnvHookPhase, phase),
+		fmt.Sprintf("%s=%s", EnvHookCommand, strings.Join(commandPath, " ")),
+		fmt.Sprintf("%s=%s", EnvHookArgs, strings.Join(args, " ")),
+	)
+	cmd.Env = append(cmd.Env, env...)
+	return cmd.Run()
+}
+
+func hookName(hook *config.Hook) string {
+	if hook.Name != "" {
+		return hook.Name
+	}
+	if len(hook.Pre) > 0 {
+		return hook.Pre[0]
+	}
+	if len(hook.Post) > 0 {
+		return hook.Post[0]
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package hooks_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/hooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatches(t *testing.T) {
+	testCases := []struct {
+		commands []string
+		args     []string
+		expected bool
+	}{
+		{[]string{"promote"}, []string{"promote", "myapp", "--env", "production"}, true},
+		{[]string{"admin operator"}, []string{"admin", "operator"}, true},
+		{[]string{"admin *"}, []string{"admin", "log"}, true},
+		{[]string{"admin *"}, []string{"admin"}, false},
+		{[]string{"gitops"}, []string{"pipeline", "gitops"}, false},
+		{[]string{"*"}, []string{"namespace", "--batch-mode"}, true},
+		{[]string{"admin", "promote"}, []string{"promote"}, true},
+	}
+	for _, tc := range testCases {
+		hook := &config.Hook{Commands: tc.commands}
+		actual := hooks.Matches(hook, hooks.CommandPath(tc.args))
+		assert.Equal(t, tc.expected, actual, "for commands %v and args %v", tc.commands, tc.args)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script hooks")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-hooks-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	script := filepath.Join(tmpDir, "hook.sh")
+	err = ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"$JX_HOOK_PHASE $JX_HOOK_COMMAND $JX_HOOK_EXIT_CODE $FOO\"\nexit $1\n"), 0755)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	r := &hooks.Runner{
+		Hooks: []config.Hook{
+			{
+				Name:     "guard",
+				Commands: []string{"promote"},
+				Pre:      []string{script, "0"},
+				Post:     []string{script, "0"},
+			},
+			{
+				Name:     "deny",
+				Commands: []string{"admin operator"},
+				Pre:      []string{script, "1"},
+			},
+		},
+		Environ: []string{"FOO=bar"},
+		Out:     out,
+		Err:     out,
+	}
+
+	args := []string{"promote", "myapp"}
+	commandPath := hooks.CommandPath(args)
+	assert.True(t, r.HasHooks(hooks.PhasePost, commandPath))
+	require.NoError(t, r.Pre(commandPath, args))
+	r.Post(commandPath, args, 3, time.Second)
+	assert.Equal(t, "pre promote myapp  bar\npost promote myapp 3 bar\n", out.String())
+
+	args = []string{"admin", "operator"}
+	commandPath = hooks.CommandPath(args)
+	assert.False(t, r.HasHooks(hooks.PhasePost, commandPath))
+	err = r.Pre(commandPath, args)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pre hook deny failed")
+}
@@ -0,0 +1,290 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// EnvOutputFile the environment variable for the file plugins append JSON lines of results to
+	EnvOutputFile = "JX_OUTPUT_FILE"
+
+	// FlagName the name of the root flag for the output format
+	FlagName = "output"
+
+	// FormatTable renders results as a table
+	FormatTable = "table"
+	// FormatJSON renders results as JSON
+	FormatJSON = "json"
+	// FormatYAML renders results as YAML
+	FormatYAML = "yaml"
+	// FormatGoTemplate renders results using a go template such as `go-template={{range .}}{{.version}}{{end}}`
+	FormatGoTemplate = "go-template"
+	// FormatJSONPath renders results using a JSONPath expression such as `jsonpath={[*].version}`
+	FormatJSONPath = "jsonpath"
+)
+
+// Formats the supported output formats
+var Formats = []string{FormatTable, FormatJSON, FormatYAML, FormatGoTemplate + "=...", FormatJSONPath + "=..."}
+
+// Result a typed result emitted by a command or plugin
+type Result struct {
+	// Kind the type of the result such as `Version`
+	Kind string `json:"kind"`
+	// Columns the fields of the items shown as table columns. Defaults to all the fields of the first item
+	Columns []string `json:"columns,omitempty"`
+	// Items the result items
+	Items []map[string]interface{} `json:"items"`
+}
+
+// NewResult creates a result converting the items to JSON objects
+func NewResult(kind string, columns []string, items ...interface{}) (*Result, error) {
+	r := &Result{
+		Kind:    kind,
+		Columns: columns,
+	}
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal %s", kind)
+		}
+		m := map[string]interface{}{}
+		err = json.Unmarshal(data, &m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %s to an object", kind)
+		}
+		r.Items = append(r.Items, m)
+	}
+	return r, nil
+}
+
+// Format an output format
+type Format struct {
+	// Name the name of the format such as FormatJSON
+	Name string
+	// Template the template or JSONPath expression for FormatGoTemplate and FormatJSONPath
+	Template string
+}
+
+// ParseFormat parses an output format such as `json` or `jsonpath={[*].version}` returning nil if the text is empty
+func ParseFormat(text string) (*Format, error) {
+	if text == "" {
+		return nil, nil
+	}
+	name := text
+	tmpl := ""
+	if idx := strings.Index(text, "="); idx > 0 {
+		name = text[:idx]
+		tmpl = text[idx+1:]
+	}
+	switch name {
+	case FormatTable, FormatJSON, FormatYAML:
+		if tmpl != "" {
+			return nil, errors.Errorf("output format %s does not take a template", name)
+		}
+	case FormatGoTemplate, FormatJSONPath:
+		if tmpl == "" {
+			return nil, errors.Errorf("output format %s requires a template such as %s=...", name, name)
+		}
+	default:
+		return nil, errors.Errorf("unsupported output format %s; supported formats are: %s", name, strings.Join(Formats, ", "))
+	}
+	return &Format{Name: name, Template: tmpl}, nil
+}
+
+// AddFlag adds the output format flag to the command
+func AddFlag(cmd *cobra.Command, value *string) {
+	cmd.PersistentFlags().StringVar(value, FlagName, "", "Renders the output of commands which support structured output in a format: "+strings.Join(Formats, ", "))
+}
+
+// FormatFromCommand returns the output format from the flag on the command or its parents returning nil if there is none
+func FormatFromCommand(cmd *cobra.Command) (*Format, error) {
+	f := cmd.Flag(FlagName)
+	if f == nil {
+		return nil, nil
+	}
+	return ParseFormat(f.Value.String())
+}
+
+// Render renders the results in the format
+func Render(w io.Writer, format *Format, results []Result) error {
+	if format == nil || format.Name == FormatTable {
+		return renderTable(w, results)
+	}
+	data := resultData(results)
+	switch format.Name {
+	case FormatJSON:
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal results to JSON")
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case FormatYAML:
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal results to YAML")
+		}
+		_, err = w.Write(out)
+		return err
+	case FormatGoTemplate:
+		t, err := template.New("output").Parse(format.Template)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse go template %s", format.Template)
+		}
+		err = t.Execute(w, data)
+		if err != nil {
+			return errors.Wrap(err, "failed to render go template")
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	case FormatJSONPath:
+		expression := format.Template
+		if !strings.HasPrefix(expression, "{") {
+			expression = "{" + expression + "}"
+		}
+		p := jsonpath.New("output")
+		err := p.Parse(expression)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse jsonpath %s", format.Template)
+		}
+		err = p.Execute(w, data)
+		if err != nil {
+			return errors.Wrap(err, "failed to render jsonpath")
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	default:
+		return errors.Errorf("unsupported output format %s", format.Name)
+	}
+}
+
+// resultData returns the list of items from all of the results.
+// A list is returned even for a single item so the shape of the output does not depend on the number of items
+func resultData(results []Result) []interface{} {
+	items := []interface{}{}
+	for _, r := range results {
+		for _, item := range r.Items {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func renderTable(w io.Writer, results []Result) error {
+	for i, r := range results {
+		if len(r.Items) == 0 {
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		columns := r.Columns
+		if len(columns) == 0 {
+			for k := range r.Items[0] {
+				columns = append(columns, k)
+			}
+			sort.Strings(columns)
+		}
+		t := table.CreateTable(w)
+		var header []string
+		for _, c := range columns {
+			header = append(header, strings.ToUpper(c))
+		}
+		t.AddRow(header...)
+		for _, item := range r.Items {
+			var row []string
+			for _, c := range columns {
+				row = append(row, formatValue(item[c]))
+			}
+			t.AddRow(row...)
+		}
+		t.Render()
+	}
+	return nil
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Requested returns true if the host jx has requested structured results from a plugin
+func Requested() bool {
+	return os.Getenv(EnvOutputFile) != ""
+}
+
+// Emit writes the result to the host jx if it has requested structured results via $JX_OUTPUT_FILE.
+// Returns false if structured results were not requested so that the plugin can output them itself
+func Emit(r *Result) (bool, error) {
+	fileName := os.Getenv(EnvOutputFile)
+	if fileName == "" {
+		return false, nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to marshal %s", r.Kind)
+	}
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to open output file %s", fileName)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to write output file %s", fileName)
+	}
+	return true, nil
+}
+
+// LoadResults loads the JSON lines of results written by a plugin to the file
+func LoadResults(fileName string) ([]Result, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read output file %s", fileName)
+	}
+	var answer []Result
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		r := Result{}
+		err = json.Unmarshal([]byte(text), &r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse result on line %d of %s", line, fileName)
+		}
+		answer = append(answer, r)
+	}
+	return answer, nil
+}
@@ -0,0 +1,99 @@
+package output_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	f, err := output.ParseFormat("")
+	require.NoError(t, err)
+	assert.Nil(t, f)
+
+	f, err = output.ParseFormat("go-template={{.version}}")
+	require.NoError(t, err)
+	assert.Equal(t, &output.Format{Name: output.FormatGoTemplate, Template: "{{.version}}"}, f)
+
+	_, err = output.ParseFormat("jsonpath")
+	assert.Error(t, err, "jsonpath requires a template")
+
+	_, err = output.ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	r, err := output.NewResult("Version", []string{"version", "goVersion"}, map[string]string{
+		"version":   "3.1.0",
+		"goVersion": "1.15",
+	})
+	require.NoError(t, err)
+	results := []output.Result{*r}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   "json",
+			expected: "[\n  {\n    \"goVersion\": \"1.15\",\n    \"version\": \"3.1.0\"\n  }\n]\n",
+		},
+		{
+			format:   "yaml",
+			expected: "- goVersion: \"1.15\"\n  version: 3.1.0\n",
+		},
+		{
+			format:   "go-template={{range .}}{{.version}}{{end}}",
+			expected: "3.1.0\n",
+		},
+		{
+			format:   "jsonpath=[*].goVersion",
+			expected: "1.15\n",
+		},
+	}
+	for _, tc := range testCases {
+		f, err := output.ParseFormat(tc.format)
+		require.NoError(t, err, "format %s", tc.format)
+		out := &bytes.Buffer{}
+		err = output.Render(out, f, results)
+		require.NoError(t, err, "format %s", tc.format)
+		assert.Equal(t, tc.expected, out.String(), "format %s", tc.format)
+	}
+
+	f, err := output.ParseFormat("table")
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	err = output.Render(out, f, results)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "VERSION")
+	assert.Contains(t, out.String(), "3.1.0")
+}
+
+func TestEmitAndLoadResults(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-output-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	fileName := filepath.Join(tmpDir, "results.jsonl")
+	os.Setenv(output.EnvOutputFile, fileName)
+	defer os.Unsetenv(output.EnvOutputFile)
+
+	for _, name := range []string{"a", "b"} {
+		r, err := output.NewResult("Thing", nil, map[string]string{"name": name})
+		require.NoError(t, err)
+		emitted, err := output.Emit(r)
+		require.NoError(t, err)
+		assert.True(t, emitted)
+	}
+
+	results, err := output.LoadResults(fileName)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "b", results[1].Items[0]["name"])
+}
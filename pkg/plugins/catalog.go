@@ -0,0 +1,208 @@
+package plugins
+
+import (
+	// embed the plugin catalog
+	_ "embed"
+	"fmt"
+
+	"github.com/blang/semver"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/jenkins-x/jx/pkg/plugins/oci"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed catalog.yaml
+var catalogYAML []byte
+
+// Catalog the catalog of the default plugins and the other dependencies of the jx CLI
+type Catalog struct {
+	// Plugins the default plugins
+	Plugins []CatalogEntry `json:"plugins"`
+	// Dependencies the other dependencies which are tracked in the dependency matrix
+	Dependencies []CatalogEntry `json:"dependencies,omitempty"`
+}
+
+// CatalogEntry a plugin or dependency in the catalog
+type CatalogEntry struct {
+	// Name the name of the plugin such as `gitops` or the repository name of a dependency
+	Name string `json:"name"`
+	// Org the GitHub organisation which releases it. Not required for plugins with an OCI source
+	Org string `json:"org,omitempty"`
+	// Source the OCI repository the plugin is published to such as `oci://ghcr.io/myorg/jx-foo` rather than a GitHub
+	// release. The version is used as the tag
+	Source string `json:"source,omitempty"`
+	// Version the version to use
+	Version string `json:"version"`
+	// Description the description
+	Description string `json:"description,omitempty"`
+	// Boot true if the plugin is required to boot an installation
+	Boot bool `json:"boot,omitempty"`
+	// Mandatory true if the plugin is always installed by `jx upgrade plugins --mandatory`
+	Mandatory bool `json:"mandatory,omitempty"`
+	// Image true if the dependency is a container image rather than a GitHub release
+	Image bool `json:"image,omitempty"`
+}
+
+// Repository returns the name of the repository of the plugin or dependency
+func (e *CatalogEntry) Repository(plugin bool) string {
+	if plugin {
+		return "jx-" + e.Name
+	}
+	return e.Name
+}
+
+// DefaultCatalog the catalog embedded in the jx binary
+var DefaultCatalog = mustLoadCatalog(catalogYAML)
+
+// LoadCatalog parses and validates the catalog
+func LoadCatalog(data []byte) (*Catalog, error) {
+	catalog := &Catalog{}
+	err := yaml.Unmarshal(data, catalog)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the plugin catalog")
+	}
+	err = catalog.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid plugin catalog")
+	}
+	return catalog, nil
+}
+
+// Validate validates the catalog
+func (c *Catalog) Validate() error {
+	if len(c.Plugins) == 0 {
+		return errors.Errorf("no plugins")
+	}
+	names := map[string]bool{}
+	validate := func(kind string, e *CatalogEntry) error {
+		if e.Name == "" {
+			return errors.Errorf("missing name for %s", kind)
+		}
+		if names[e.Name] {
+			return errors.Errorf("duplicate %s %s", kind, e.Name)
+		}
+		names[e.Name] = true
+		if e.Org == "" && e.Source == "" {
+			return errors.Errorf("missing org for %s %s", kind, e.Name)
+		}
+		if _, err := semver.Parse(e.Version); err != nil {
+			return errors.Wrapf(err, "invalid version %q for %s %s", e.Version, kind, e.Name)
+		}
+		return nil
+	}
+	for i := range c.Plugins {
+		e := &c.Plugins[i]
+		err := validate("plugin", e)
+		if err != nil {
+			return err
+		}
+		if e.Boot && !e.Mandatory {
+			return errors.Errorf("boot plugin %s must be mandatory", e.Name)
+		}
+		if e.Image {
+			return errors.Errorf("plugin %s cannot be an image", e.Name)
+		}
+		if e.Source != "" {
+			err = validateSource(e)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for i := range c.Dependencies {
+		e := &c.Dependencies[i]
+		err := validate("dependency", e)
+		if err != nil {
+			return err
+		}
+		if e.Boot || e.Mandatory {
+			return errors.Errorf("dependency %s cannot be a boot or mandatory plugin", e.Name)
+		}
+		if e.Source != "" {
+			return errors.Errorf("dependency %s cannot have a source", e.Name)
+		}
+	}
+	return nil
+}
+
+// validateSource validates the OCI source of a plugin which must not include a tag or digest
+func validateSource(e *CatalogEntry) error {
+	ref, err := oci.ParseReference(e.Source)
+	if err != nil {
+		return errors.Wrapf(err, "invalid source for plugin %s", e.Name)
+	}
+	if oci.Scheme+ref.Name() != e.Source {
+		return errors.Errorf("source %s of plugin %s must not include a tag or digest as the version is used as the tag", e.Source, e.Name)
+	}
+	return nil
+}
+
+// CreatePlugins creates the plugins in the catalog
+func (c *Catalog) CreatePlugins() []jenkinsv1.Plugin {
+	var answer []jenkinsv1.Plugin
+	for i := range c.Plugins {
+		e := &c.Plugins[i]
+		var p jenkinsv1.Plugin
+		if e.Source != "" {
+			p = CreateOCIPlugin(e.Name, e.Version, e.Source+":"+e.Version)
+		} else {
+			p = extensions.CreateJXPlugin(e.Org, e.Name, e.Version)
+		}
+		if e.Description != "" {
+			p.Spec.Description = e.Description
+		}
+		answer = append(answer, p)
+	}
+	return answer
+}
+
+// Lookup returns the catalog entry of the plugin with the given name such as `gitops` or `jx-gitops` or nil if there is none
+func (c *Catalog) Lookup(name string) *CatalogEntry {
+	for i := range c.Plugins {
+		e := &c.Plugins[i]
+		if e.Name == name || "jx-"+e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// BootPlugins returns the names of the plugins required to boot an installation
+func (c *Catalog) BootPlugins() map[string]bool {
+	answer := map[string]bool{}
+	for _, e := range c.Plugins {
+		if e.Boot {
+			answer[e.Name] = true
+		}
+	}
+	return answer
+}
+
+// DependencyVersion returns the version of the dependency of the given name or panics if it is not in the catalog
+func (c *Catalog) DependencyVersion(name string) string {
+	for _, e := range c.Dependencies {
+		if e.Name == name {
+			return e.Version
+		}
+	}
+	panic(fmt.Sprintf("no dependency %s in the plugin catalog", name))
+}
+
+// pluginVersion returns the version of the plugin of the given name or panics if it is not in the catalog
+func (c *Catalog) pluginVersion(name string) string {
+	e := c.Lookup(name)
+	if e == nil {
+		panic(fmt.Sprintf("no plugin %s in the plugin catalog", name))
+	}
+	return e.Version
+}
+
+func mustLoadCatalog(data []byte) *Catalog {
+	catalog, err := LoadCatalog(data)
+	if err != nil {
+		panic(err)
+	}
+	return catalog
+}
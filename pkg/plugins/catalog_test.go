@@ -0,0 +1,63 @@
+package plugins_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	catalog := plugins.DefaultCatalog
+	require.Len(t, plugins.Plugins, len(catalog.Plugins))
+
+	gitops := catalog.Lookup("jx-gitops")
+	require.NotNil(t, gitops)
+	assert.True(t, gitops.Boot)
+	assert.Equal(t, gitops.Version, plugins.PluginMap["jx-gitops"].Spec.Version)
+	assert.Equal(t, map[string]bool{"gitops": true, "health": true, "secret": true, "verify": true}, catalog.BootPlugins())
+}
+
+func TestDependencyMatrixUpToDate(t *testing.T) {
+	expected, err := plugins.DefaultCatalog.DependencyMatrix().YAML()
+	require.NoError(t, err)
+
+	actual, err := ioutil.ReadFile(filepath.Join("..", "..", "dependency-matrix", plugins.MatrixYAMLFile))
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual), "the dependency matrix is out of date; regenerate it via: make matrix")
+}
+
+func TestLoadCatalogValidation(t *testing.T) {
+	testCases := map[string]string{
+		"no plugins":      "plugins: []",
+		"missing org":     "plugins:\n- name: foo\n  version: 1.0.0\n",
+		"invalid version": "plugins:\n- name: foo\n  org: bar\n  version: latest\n",
+		"duplicate":       "plugins:\n- name: foo\n  org: bar\n  version: 1.0.0\n- name: foo\n  org: bar\n  version: 1.0.1\n",
+		"optional boot":   "plugins:\n- name: foo\n  org: bar\n  version: 1.0.0\n  boot: true\n",
+		"source tag":      "plugins:\n- name: foo\n  source: oci://ghcr.io/bar/jx-foo:1.0.0\n  version: 1.0.0\n",
+		"not oci":         "plugins:\n- name: foo\n  source: https://example.com/jx-foo\n  version: 1.0.0\n",
+	}
+	for name, text := range testCases {
+		_, err := plugins.LoadCatalog([]byte(text))
+		assert.Error(t, err, "for %s", name)
+	}
+
+	c, err := plugins.LoadCatalog([]byte("plugins:\n- name: foo\n  org: bar\n  version: 1.0.0\n  mandatory: true\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "jx-foo", c.CreatePlugins()[0].Spec.Name)
+
+	c, err = plugins.LoadCatalog([]byte("plugins:\n- name: foo\n  source: oci://ghcr.io/bar/jx-foo\n  version: 1.0.0\n"))
+	require.NoError(t, err, "plugins with an OCI source do not need an org")
+	p := c.CreatePlugins()[0]
+	assert.Equal(t, "jx-foo", p.Spec.Name)
+	assert.Equal(t, "1.0.0", p.Spec.Version)
+	require.NotEmpty(t, p.Spec.Binaries)
+	assert.Equal(t, "oci://ghcr.io/bar/jx-foo:1.0.0", p.Spec.Binaries[0].URL)
+	d := c.DependencyMatrix().Dependencies[0]
+	assert.Equal(t, "ghcr.io", d.Host)
+	assert.Equal(t, "bar", d.Owner)
+	assert.Equal(t, "jx-foo", d.Repo)
+}
@@ -0,0 +1,57 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ChecksumFileSuffix the suffix of the file next to an installed plugin binary containing its SHA-256 checksum
+const ChecksumFileSuffix = ".sha256"
+
+// Checksum returns the hex encoded SHA-256 checksum of the file
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", path)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksum records the checksum of a newly installed plugin binary
+func WriteChecksum(path string) error {
+	sum, err := Checksum(path)
+	if err != nil {
+		return err
+	}
+	fileName := path + ChecksumFileSuffix
+	err = ioutil.WriteFile(fileName, []byte(sum+"\n"), 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s", fileName)
+	}
+	return nil
+}
+
+// RecordedChecksum returns the checksum recorded when the plugin binary was installed or an empty string if there is none
+func RecordedChecksum(path string) (string, error) {
+	fileName := path + ChecksumFileSuffix
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to read %s", fileName)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,253 @@
+package plugins
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/pkg/errors"
+)
+
+var (
+	// versionPattern matches the semantic versions in the output of the version command of a plugin
+	versionPattern = regexp.MustCompile(`v?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?`)
+
+	elfArchs = map[elf.Machine]string{
+		elf.EM_386:     "386",
+		elf.EM_X86_64:  "amd64",
+		elf.EM_ARM:     "arm",
+		elf.EM_AARCH64: "arm64",
+		elf.EM_PPC64:   "ppc64le",
+		elf.EM_S390:    "s390x",
+	}
+
+	machoArchs = map[macho.Cpu]string{
+		macho.Cpu386:   "386",
+		macho.CpuAmd64: "amd64",
+		macho.CpuArm:   "arm",
+		macho.CpuArm64: "arm64",
+	}
+
+	peArchs = map[uint16]string{
+		pe.IMAGE_FILE_MACHINE_I386:  "386",
+		pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+		pe.IMAGE_FILE_MACHINE_ARMNT: "arm",
+		pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+	}
+)
+
+// Doctor checks installed plugin binaries are valid
+type Doctor struct {
+	// GOOS the operating system the plugins should be built for. Defaults to the current one
+	GOOS string
+	// GOARCH the architecture the plugins should be built for. Defaults to the current one
+	GOARCH string
+	// CommandRunner runs the plugins to check their version
+	CommandRunner cmdrunner.CommandRunner
+	// Timeout the timeout for running the version command of a plugin
+	Timeout time.Duration
+}
+
+// Diagnose checks the plugin binary at the path is executable, built for the current platform, matches the
+// checksum recorded when it was installed and reports the given version. If version is empty the version is not checked.
+// Returns the problems found
+func (d *Doctor) Diagnose(path, version string) []string {
+	err := CheckExecutable(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	goos, goarch := d.GOOS, d.GOARCH
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	err = CheckPlatform(path, goos, goarch)
+	if err != nil {
+		// there's no point running a binary for a different platform
+		return append(problems, err.Error())
+	}
+
+	expected, err := RecordedChecksum(path)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	if expected != "" {
+		actual, err := Checksum(path)
+		if err != nil {
+			return append(problems, err.Error())
+		}
+		if actual != expected {
+			problems = append(problems, fmt.Sprintf("checksum %s does not match %s recorded at install", actual, expected))
+		}
+	}
+
+	if version != "" {
+		err = d.checkVersion(path, version)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+func (d *Doctor) checkVersion(path, version string) error {
+	runner := d.CommandRunner
+	if runner == nil {
+		runner = cmdrunner.QuietCommandRunner
+	}
+	timeout := d.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	c := &cmdrunner.Command{
+		Name:    path,
+		Args:    []string{"version"},
+		Timeout: timeout,
+	}
+	text, err := runner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run %s version", path)
+	}
+	if !reportsVersion(text, version) {
+		return errors.Errorf("version reports %q but expected version %s", strings.TrimSpace(text), version)
+	}
+	return nil
+}
+
+// reportsVersion returns true if any semantic version in the output of the version command equals the version
+func reportsVersion(text, version string) bool {
+	expected, err := semver.ParseTolerant(version)
+	if err != nil {
+		return false
+	}
+	for _, token := range versionPattern.FindAllString(text, -1) {
+		v, err := semver.ParseTolerant(token)
+		if err == nil && v.EQ(expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckExecutable checks the file exists and is an executable file
+func CheckExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("file %s does not exist", path)
+		}
+		return errors.Wrapf(err, "failed to check file %s", path)
+	}
+	if info.IsDir() {
+		return errors.Errorf("%s is a directory", path)
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		return errors.Errorf("file %s is not executable", path)
+	}
+	return nil
+}
+
+// CheckPlatform checks the ELF, Mach-O or PE header of the binary matches the given operating system and architecture.
+// Scripts are assumed to be valid for all platforms
+func CheckPlatform(path, goos, goarch string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+
+	header := make([]byte, 4)
+	_, err = io.ReadFull(f, header)
+	if err != nil {
+		return errors.Errorf("%s is not a valid executable", path)
+	}
+
+	binaryOS := ""
+	var binaryArchs []string
+	switch {
+	case bytes.HasPrefix(header, []byte("#!")):
+		return nil
+
+	case bytes.Equal(header, []byte(elf.ELFMAG)):
+		ef, err := elf.NewFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "%s is not a valid ELF binary", path)
+		}
+		binaryOS = "linux"
+		if ef.OSABI == elf.ELFOSABI_FREEBSD {
+			binaryOS = "freebsd"
+		}
+		binaryArchs = append(binaryArchs, elfArchs[ef.Machine])
+
+	case bytes.Equal(header, []byte{0xca, 0xfe, 0xba, 0xbe}):
+		ff, err := macho.NewFatFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "%s is not a valid Mach-O binary", path)
+		}
+		binaryOS = "darwin"
+		for _, a := range ff.Arches {
+			binaryArchs = append(binaryArchs, machoArchs[a.Cpu])
+		}
+
+	case isMachO(header):
+		mf, err := macho.NewFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "%s is not a valid Mach-O binary", path)
+		}
+		binaryOS = "darwin"
+		binaryArchs = append(binaryArchs, machoArchs[mf.Cpu])
+
+	case bytes.HasPrefix(header, []byte("MZ")):
+		pf, err := pe.NewFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "%s is not a valid PE binary", path)
+		}
+		binaryOS = "windows"
+		binaryArchs = append(binaryArchs, peArchs[pf.Machine])
+
+	default:
+		return errors.Errorf("%s is not a recognised executable", path)
+	}
+
+	for i, a := range binaryArchs {
+		if a == "" {
+			binaryArchs[i] = "unknown"
+		}
+	}
+	if binaryOS != goos {
+		return errors.Errorf("binary is built for %s but this is %s", binaryOS, goos)
+	}
+	for _, a := range binaryArchs {
+		if a == goarch {
+			return nil
+		}
+	}
+	return errors.Errorf("binary is built for %s/%s but this is %s/%s", binaryOS, strings.Join(binaryArchs, ","), goos, goarch)
+}
+
+func isMachO(header []byte) bool {
+	for _, magic := range []uint32{macho.Magic32, macho.Magic64} {
+		b := []byte{byte(magic >> 24), byte(magic >> 16), byte(magic >> 8), byte(magic)}
+		if bytes.Equal(header, b) {
+			return true
+		}
+		// lets check the little endian form too
+		if bytes.Equal(header, []byte{b[3], b[2], b[1], b[0]}) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package plugins_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPlatform(t *testing.T) {
+	// the test binary is built for the current platform
+	binary := os.Args[0]
+	require.NoError(t, plugins.CheckPlatform(binary, runtime.GOOS, runtime.GOARCH))
+
+	otherOS := "darwin"
+	if runtime.GOOS == "darwin" {
+		otherOS = "linux"
+	}
+	assert.Error(t, plugins.CheckPlatform(binary, otherOS, runtime.GOARCH), "should fail for a different OS")
+
+	otherArch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		otherArch = "amd64"
+	}
+	assert.Error(t, plugins.CheckPlatform(binary, runtime.GOOS, otherArch), "should fail for a different architecture")
+
+	tmpDir, err := ioutil.TempDir("", "test-doctor-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	script := filepath.Join(tmpDir, "script")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho hello\n"), 0755))
+	assert.NoError(t, plugins.CheckPlatform(script, "darwin", "arm64"), "scripts should work on any platform")
+
+	garbage := filepath.Join(tmpDir, "garbage")
+	require.NoError(t, ioutil.WriteFile(garbage, []byte("this is not a binary"), 0755))
+	assert.Error(t, plugins.CheckPlatform(garbage, runtime.GOOS, runtime.GOARCH))
+}
+
+func TestDoctorDiagnose(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-doctor-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "jx-foo-1.2.3")
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\necho version: 1.2.3\n"), 0755))
+	require.NoError(t, plugins.WriteChecksum(path))
+
+	d := &plugins.Doctor{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			return "version: 1.2.3", nil
+		},
+	}
+	assert.Empty(t, d.Diagnose(path, "1.2.3"))
+	assert.Len(t, d.Diagnose(path, "1.3.0"), 1, "should report the wrong version")
+	assert.Len(t, d.Diagnose(path, "1.2"), 1, "should not match part of the reported version")
+
+	for reported, expected := range map[string]string{"0.0.189": "0.0.1", "10.1.40": "0.1.4"} {
+		reported := reported
+		vd := &plugins.Doctor{
+			CommandRunner: func(c *cmdrunner.Command) (string, error) {
+				return "version: " + reported, nil
+			},
+		}
+		assert.Len(t, vd.Diagnose(path, expected), 1, "should not accept %s when the plugin reports %s", expected, reported)
+		assert.Empty(t, vd.Diagnose(path, "v"+reported), "should accept %s", reported)
+	}
+
+	// lets corrupt the binary
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\necho corrupt\n"), 0755))
+	assert.Len(t, d.Diagnose(path, "1.2.3"), 1, "should report the checksum mismatch")
+
+	require.NoError(t, os.Chmod(path, 0644))
+	assert.Len(t, d.Diagnose(path, "1.2.3"), 1, "should report the file is not executable")
+
+	assert.Len(t, d.Diagnose(filepath.Join(tmpDir, "does-not-exist"), ""), 1)
+
+	// the checksum file should not be mistaken for a version
+	_, version, err := plugins.FindInstalledPlugin(tmpDir, "jx-foo")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", version)
+}
@@ -2,12 +2,15 @@ package plugins
 
 import (
 	"fmt"
+	"net/url"
+	"path"
 	"runtime"
 	"strings"
 
 	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
+	"github.com/jenkins-x/jx/pkg/plugins/oci"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -136,3 +139,29 @@ func CreateOctantJXOPlugin(version string) jenkinsv1.Plugin {
 	plugin.Spec.SubCommand = OctantJXOPluginName
 	return plugin
 }
+
+// PluginSource returns the source of the plugin such as `github.com/jenkins-x-plugins/jx-gitops` from its binary URLs
+func PluginSource(plugin *jenkinsv1.Plugin) string {
+	for _, b := range plugin.Spec.Binaries {
+		if oci.IsReference(b.URL) {
+			ref, err := oci.ParseReference(b.URL)
+			if err != nil {
+				continue
+			}
+			return ref.Name()
+		}
+		u, err := url.Parse(b.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		p := u.Path
+		idx := strings.Index(p, "/releases/")
+		if idx >= 0 {
+			p = p[:idx]
+		} else {
+			p = path.Dir(p)
+		}
+		return u.Host + strings.TrimSuffix(p, "/")
+	}
+	return ""
+}
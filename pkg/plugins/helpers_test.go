@@ -5,12 +5,15 @@ import (
 
 	"github.com/jenkins-x/jx/pkg/plugins"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPlugins(t *testing.T) {
 	t.Parallel()
 
 	list := plugins.Plugins
+	entry := plugins.DefaultCatalog.Lookup("jx-gitops")
+	require.NotNil(t, entry, "no jx-gitops in the catalog")
 
 	for _, p := range list {
 		if p.Name != "gitops" {
@@ -23,11 +26,11 @@ func TestPlugins(t *testing.T) {
 		for _, b := range p.Spec.Binaries {
 			if b.Goos == "Linux" && b.Goarch == "amd64" {
 				foundLinux = true
-				assert.Equal(t, "https://github.com/jenkins-x-plugins/jx-gitops/releases/download/v"+plugins.GitOpsVersion+"/jx-gitops-linux-amd64.tar.gz", b.URL, "URL for linux binary")
+				assert.Equal(t, "https://github.com/jenkins-x-plugins/jx-gitops/releases/download/v"+p.Spec.Version+"/jx-gitops-linux-amd64.tar.gz", b.URL, "URL for linux binary")
 				t.Logf("found linux binary URL %s", b.URL)
 			} else if b.Goos == "Windows" && b.Goarch == "amd64" {
 				foundWindows = true
-				assert.Equal(t, "https://github.com/jenkins-x-plugins/jx-gitops/releases/download/v"+plugins.GitOpsVersion+"/jx-gitops-windows-amd64.zip", b.URL, "URL for windows binary")
+				assert.Equal(t, "https://github.com/jenkins-x-plugins/jx-gitops/releases/download/v"+p.Spec.Version+"/jx-gitops-windows-amd64.zip", b.URL, "URL for windows binary")
 				t.Logf("found windows binary URL %s", b.URL)
 			}
 		}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/jenkins-x/jx/pkg/plugins/host"
+	"github.com/pkg/errors"
+)
+
+// Client a reference client for plugins to make requests to the host jx
+type Client struct {
+	reader *bufio.Reader
+	writer io.Writer
+	closer []io.Closer
+	lock   sync.Mutex
+	nextID int64
+}
+
+// Offered returns true if the host jx has offered the protocol to this process
+func Offered() bool {
+	return os.Getenv(host.EnvProtocol) == host.ProtocolVersion
+}
+
+// New creates a client for the protocol offered by the host jx.
+// Returns nil if the plugin was not invoked by a host which offers the protocol
+// so that plugins can fall back to resolving things themselves
+func New() *Client {
+	if !Offered() {
+		return nil
+	}
+	w := os.NewFile(uintptr(host.RequestFD), "jx-host-requests")
+	r := os.NewFile(uintptr(host.ResponseFD), "jx-host-responses")
+	if w == nil || r == nil {
+		return nil
+	}
+	c := NewClient(r, w)
+	c.closer = []io.Closer{w, r}
+	return c
+}
+
+// NewClient creates a client which reads responses from the reader and writes requests to the writer
+func NewClient(r io.Reader, w io.Writer) *Client {
+	return &Client{
+		reader: bufio.NewReader(r),
+		writer: w,
+	}
+}
+
+// Close closes the connection to the host
+func (c *Client) Close() error {
+	for _, closer := range c.closer {
+		err := closer.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Call invokes the method with the parameters on the host and unmarshals the result
+func (c *Client) Call(method string, params, result interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.nextID++
+	id := json.RawMessage(strconv.FormatInt(c.nextID, 10))
+	err := c.write(&host.Request{ID: id, Method: method}, params)
+	if err != nil {
+		return err
+	}
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return errors.Wrapf(err, "failed to read response to %s", method)
+	}
+	resp := &host.Response{}
+	err = json.Unmarshal(line, resp)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse response to %s", method)
+	}
+	if string(resp.ID) != string(id) {
+		return errors.Errorf("received response %s for request %s", string(resp.ID), string(id))
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(resp.Result, result)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse result of %s", method)
+	}
+	return nil
+}
+
+// Notify sends a notification to the host which has no response
+func (c *Client) Notify(method string, params interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.write(&host.Request{Method: method}, params)
+}
+
+func (c *Client) write(req *host.Request, params interface{}) error {
+	req.JSONRPC = host.JSONRPCVersion
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal parameters of %s", req.Method)
+		}
+		req.Params = data
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal request %s", req.Method)
+	}
+	_, err = c.writer.Write(append(data, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "failed to send request %s", req.Method)
+	}
+	return nil
+}
+
+// Info returns the information about the host
+func (c *Client) Info() (*host.Info, error) {
+	answer := &host.Info{}
+	err := c.Call(host.MethodInfo, nil, answer)
+	return answer, err
+}
+
+// KubeContext returns the kubernetes context and namespace resolved by the host
+func (c *Client) KubeContext() (*host.KubeContext, error) {
+	answer := &host.KubeContext{}
+	err := c.Call(host.MethodKubeContext, nil, answer)
+	return answer, err
+}
+
+// Prompt prompts the user via the host
+func (c *Client) Prompt(params *host.PromptParams) (*host.PromptResult, error) {
+	answer := &host.PromptResult{}
+	err := c.Call(host.MethodPrompt, params, answer)
+	return answer, err
+}
+
+// PickValue prompts the user for a value
+func (c *Client) PickValue(message, defaultValue string, required bool, help string) (string, error) {
+	r, err := c.Prompt(&host.PromptParams{
+		Type:     host.PromptText,
+		Message:  message,
+		Default:  defaultValue,
+		Required: required,
+		Help:     help,
+	})
+	return r.Value, err
+}
+
+// PickPassword prompts the user for a password
+func (c *Client) PickPassword(message, help string) (string, error) {
+	r, err := c.Prompt(&host.PromptParams{
+		Type:     host.PromptPassword,
+		Message:  message,
+		Required: true,
+		Help:     help,
+	})
+	return r.Value, err
+}
+
+// PickNameWithDefault prompts the user to pick one of the names
+func (c *Client) PickNameWithDefault(names []string, message, defaultValue, help string) (string, error) {
+	r, err := c.Prompt(&host.PromptParams{
+		Type:    host.PromptSelect,
+		Message: message,
+		Default: defaultValue,
+		Options: names,
+		Help:    help,
+	})
+	return r.Value, err
+}
+
+// Confirm prompts the user to confirm
+func (c *Client) Confirm(message string, defaultValue bool, help string) (bool, error) {
+	r, err := c.Prompt(&host.PromptParams{
+		Type:    host.PromptConfirm,
+		Message: message,
+		Default: strconv.FormatBool(defaultValue),
+		Help:    help,
+	})
+	return r.Confirmed, err
+}
+
+// Progress reports progress via the host. The total is optional
+func (c *Client) Progress(message string, current, total int) error {
+	return c.Notify(host.MethodProgress, &host.ProgressParams{
+		Message: message,
+		Current: current,
+		Total:   total,
+	})
+}
+
+// Log logs the message via the host at the level which is one of debug, info, warn or error
+func (c *Client) Log(level, message string) error {
+	return c.Notify(host.MethodLog, &host.LogParams{
+		Level:   level,
+		Message: message,
+	})
+}
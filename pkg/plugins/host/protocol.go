@@ -0,0 +1,144 @@
+package host
+
+import (
+	"encoding/json"
+)
+
+const (
+	// EnvProtocol the environment variable the host sets to the protocol version when it offers the protocol to a plugin
+	EnvProtocol = "JX_HOST_PROTOCOL"
+
+	// EnvEnabled the environment variable which must be set to true for the host to offer the protocol to plugins
+	EnvEnabled = "JX_ENABLE_HOST_PROTOCOL"
+
+	// ProtocolVersion the current version of the protocol
+	ProtocolVersion = "1"
+
+	// RequestFD the file descriptor the plugin writes requests to
+	RequestFD = 3
+
+	// ResponseFD the file descriptor the plugin reads responses from
+	ResponseFD = 4
+
+	// JSONRPCVersion the JSON-RPC version of the messages
+	JSONRPCVersion = "2.0"
+)
+
+const (
+	// MethodInfo returns the Info of the host
+	MethodInfo = "host.info"
+
+	// MethodKubeContext returns the KubeContext resolved by the host
+	MethodKubeContext = "host.kubeContext"
+
+	// MethodPrompt prompts the user via the host using PromptParams returning a PromptResult
+	MethodPrompt = "host.prompt"
+
+	// MethodProgress a notification with ProgressParams to report progress via the host
+	MethodProgress = "host.progress"
+
+	// MethodLog a notification with LogParams to log via the host
+	MethodLog = "host.log"
+)
+
+const (
+	// ErrorCodeParse the JSON-RPC error code for invalid JSON
+	ErrorCodeParse = -32700
+	// ErrorCodeMethodNotFound the JSON-RPC error code for an unknown method
+	ErrorCodeMethodNotFound = -32601
+	// ErrorCodeInvalidParams the JSON-RPC error code for invalid parameters
+	ErrorCodeInvalidParams = -32602
+	// ErrorCodeHost the error code for a failure in the host
+	ErrorCodeHost = -32000
+)
+
+const (
+	// PromptText prompts for a line of text
+	PromptText = "text"
+	// PromptPassword prompts for a password
+	PromptPassword = "password"
+	// PromptConfirm prompts for a yes/no confirmation
+	PromptConfirm = "confirm"
+	// PromptSelect prompts to pick one of the options
+	PromptSelect = "select"
+)
+
+// Request a JSON-RPC request or notification (if there is no ID) sent by a plugin to the host
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification returns true if the request does not expect a response
+func (r *Request) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+// Response a JSON-RPC response sent by the host to a plugin
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error a JSON-RPC error
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Info the information about the host
+type Info struct {
+	// Version the version of jx
+	Version string `json:"version"`
+	// BatchMode true if the host is running in batch mode so prompts return their default values
+	BatchMode bool `json:"batchMode"`
+}
+
+// KubeContext the kubernetes context resolved by the host
+type KubeContext struct {
+	Context   string `json:"context"`
+	Namespace string `json:"namespace"`
+	Server    string `json:"server,omitempty"`
+}
+
+// PromptParams the parameters to prompt the user
+type PromptParams struct {
+	// Type the kind of prompt which defaults to text
+	Type     string   `json:"type,omitempty"`
+	Message  string   `json:"message"`
+	Help     string   `json:"help,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// PromptResult the result of a prompt
+type PromptResult struct {
+	// Value the text, password or selected option
+	Value string `json:"value,omitempty"`
+	// Confirmed the answer to a confirm prompt
+	Confirmed bool `json:"confirmed,omitempty"`
+}
+
+// ProgressParams the parameters to report progress
+type ProgressParams struct {
+	Message string `json:"message"`
+	Current int    `json:"current,omitempty"`
+	Total   int    `json:"total,omitempty"`
+}
+
+// LogParams the parameters to log a message
+type LogParams struct {
+	// Level one of debug, info, warn or error which defaults to info
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message"`
+}
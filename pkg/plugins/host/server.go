@@ -0,0 +1,301 @@
+package host
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/survey"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-kube-client/v3/pkg/kubeclient"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// drainTimeout how long to wait for any remaining requests after the plugin has completed
+var drainTimeout = time.Second
+
+// Server serves the requests of a plugin
+type Server struct {
+	// Version the version of jx
+	Version string
+	// BatchMode disables prompting so that prompts return their default values
+	BatchMode bool
+	// Input used to prompt the user. Defaults to the terminal
+	Input input.Interface
+	// KubeContext resolves the kubernetes context. Defaults to the current context of the kube config
+	KubeContext func() (*KubeContext, error)
+}
+
+// Supported returns true if the protocol can be offered to plugins on this platform.
+// Windows does not support passing extra file descriptors to child processes
+func Supported() bool {
+	return runtime.GOOS != "windows"
+}
+
+// Enabled returns true if the protocol is supported and has been enabled via the $JX_ENABLE_HOST_PROTOCOL
+// environment variable in the given environment. Plugins only run as a child process when they need the protocol
+func Enabled(environ []string) bool {
+	if !Supported() {
+		return false
+	}
+	prefix := EnvEnabled + "="
+	for _, e := range environ {
+		if strings.HasPrefix(e, prefix) {
+			return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(e, prefix))) == "true"
+		}
+	}
+	return false
+}
+
+// Attach offers the protocol to the command which has not been started yet by passing it the
+// pipes as extra files and setting the environment variable. The returned function must be called
+// after the command has completed to stop serving requests
+func (s *Server) Attach(cmd *exec.Cmd) (func(), error) {
+	requestReader, requestWriter, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request pipe")
+	}
+	responseReader, responseWriter, err := os.Pipe()
+	if err != nil {
+		requestReader.Close()
+		requestWriter.Close()
+		return nil, errors.Wrap(err, "failed to create response pipe")
+	}
+
+	// the extra files start at file descriptor 3
+	cmd.ExtraFiles = []*os.File{requestWriter, responseReader}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(append([]string{}, env...), fmt.Sprintf("%s=%s", EnvProtocol, ProtocolVersion))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := s.Serve(requestReader, responseWriter)
+		if err != nil {
+			log.Logger().Debugf("stopped serving plugin requests: %s", err.Error())
+		}
+	}()
+	return func() {
+		// lets handle any remaining requests unless a child process of the plugin still has the pipe open
+		requestWriter.Close()
+		responseReader.Close()
+		select {
+		case <-done:
+		case <-time.After(drainTimeout):
+		}
+		requestReader.Close()
+		<-done
+		responseWriter.Close()
+	}, nil
+}
+
+// Serve serves the newline delimited JSON-RPC requests from the reader until it is closed
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			resp := s.handleLine(line)
+			if resp != nil {
+				data, err := json.Marshal(resp)
+				if err != nil {
+					return errors.Wrap(err, "failed to marshal response")
+				}
+				_, err = w.Write(append(data, '\n'))
+				if err != nil {
+					return errors.Wrap(err, "failed to write response")
+				}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// handleLine handles a request returning the response or nil for a notification
+func (s *Server) handleLine(line []byte) *Response {
+	req := &Request{}
+	err := json.Unmarshal(line, req)
+	if err != nil {
+		return &Response{
+			JSONRPC: JSONRPCVersion,
+			ID:      json.RawMessage("null"),
+			Error:   &Error{Code: ErrorCodeParse, Message: err.Error()},
+		}
+	}
+	result, rpcErr := s.handle(req)
+	if req.IsNotification() {
+		if rpcErr != nil {
+			log.Logger().Debugf("failed to handle plugin notification %s: %s", req.Method, rpcErr.Message)
+		}
+		return nil
+	}
+	resp := &Response{
+		JSONRPC: JSONRPCVersion,
+		ID:      req.ID,
+		Error:   rpcErr,
+	}
+	if rpcErr == nil {
+		resp.Result, err = json.Marshal(result)
+		if err != nil {
+			resp.Error = &Error{Code: ErrorCodeHost, Message: err.Error()}
+		}
+	}
+	return resp
+}
+
+func (s *Server) handle(req *Request) (interface{}, *Error) {
+	switch req.Method {
+	case MethodInfo:
+		return &Info{Version: s.Version, BatchMode: s.BatchMode}, nil
+
+	case MethodKubeContext:
+		fn := s.KubeContext
+		if fn == nil {
+			fn = CurrentKubeContext
+		}
+		return hostResult(fn())
+
+	case MethodPrompt:
+		params := &PromptParams{}
+		if err := unmarshalParams(req, params); err != nil {
+			return nil, err
+		}
+		return hostResult(s.prompt(params))
+
+	case MethodProgress:
+		params := &ProgressParams{}
+		if err := unmarshalParams(req, params); err != nil {
+			return nil, err
+		}
+		if params.Total > 0 {
+			log.Logger().Infof("%s (%d/%d)", params.Message, params.Current, params.Total)
+		} else {
+			log.Logger().Infof("%s", params.Message)
+		}
+		return nil, nil
+
+	case MethodLog:
+		params := &LogParams{}
+		if err := unmarshalParams(req, params); err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(params.Level) {
+		case "debug":
+			log.Logger().Debug(params.Message)
+		case "warn", "warning":
+			log.Logger().Warn(params.Message)
+		case "error":
+			log.Logger().Error(params.Message)
+		default:
+			log.Logger().Info(params.Message)
+		}
+		return nil, nil
+
+	default:
+		return nil, &Error{Code: ErrorCodeMethodNotFound, Message: fmt.Sprintf("unknown method %s", req.Method)}
+	}
+}
+
+// prompt prompts the user returning the default values in batch mode
+func (s *Server) prompt(p *PromptParams) (*PromptResult, error) {
+	if p.Type == PromptConfirm {
+		defaultValue := false
+		if p.Default != "" {
+			var err error
+			defaultValue, err = strconv.ParseBool(p.Default)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid default value %s for confirm prompt", p.Default)
+			}
+		}
+		if s.BatchMode {
+			return &PromptResult{Confirmed: defaultValue}, nil
+		}
+		confirmed, err := s.input().Confirm(p.Message, defaultValue, p.Help)
+		return &PromptResult{Confirmed: confirmed}, err
+	}
+
+	if s.BatchMode {
+		if p.Required && p.Default == "" {
+			return nil, errors.Errorf("cannot prompt for %s in batch mode", p.Message)
+		}
+		return &PromptResult{Value: p.Default}, nil
+	}
+
+	var value string
+	var err error
+	switch p.Type {
+	case "", PromptText:
+		value, err = s.input().PickValue(p.Message, p.Default, p.Required, p.Help)
+	case PromptPassword:
+		value, err = s.input().PickPassword(p.Message, p.Help)
+	case PromptSelect:
+		value, err = s.input().PickNameWithDefault(p.Options, p.Message, p.Default, p.Help)
+	default:
+		return nil, errors.Errorf("unknown prompt type %s", p.Type)
+	}
+	return &PromptResult{Value: value}, err
+}
+
+func (s *Server) input() input.Interface {
+	if s.Input == nil {
+		s.Input = survey.NewInput()
+	}
+	return s.Input
+}
+
+// CurrentKubeContext returns the current context of the kube config
+func CurrentKubeContext() (*KubeContext, error) {
+	cfg, _, err := kubeclient.LoadConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the kube config")
+	}
+	answer := &KubeContext{}
+	if cfg == nil {
+		return answer, nil
+	}
+	answer.Context = cfg.CurrentContext
+	answer.Server = kube.CurrentServer(cfg)
+	ctx := kubeclient.CurrentContext(cfg)
+	if ctx != nil {
+		answer.Namespace = ctx.Namespace
+	}
+	if answer.Namespace == "" {
+		answer.Namespace = "default"
+	}
+	return answer, nil
+}
+
+func unmarshalParams(req *Request, params interface{}) *Error {
+	if len(req.Params) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(req.Params, params)
+	if err != nil {
+		return &Error{Code: ErrorCodeInvalidParams, Message: errors.Wrapf(err, "invalid parameters for %s", req.Method).Error()}
+	}
+	return nil
+}
+
+func hostResult(result interface{}, err error) (interface{}, *Error) {
+	if err != nil {
+		return nil, &Error{Code: ErrorCodeHost, Message: err.Error()}
+	}
+	return result, nil
+}
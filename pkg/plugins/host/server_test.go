@@ -0,0 +1,114 @@
+package host_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/plugins/host"
+	"github.com/jenkins-x/jx/pkg/plugins/host/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeKubeContext() (*host.KubeContext, error) {
+	return &host.KubeContext{Context: "mycluster", Namespace: "jx"}, nil
+}
+
+func TestServe(t *testing.T) {
+	s := &host.Server{
+		Version:     "3.1.0",
+		BatchMode:   true,
+		KubeContext: fakeKubeContext,
+	}
+	requestReader, requestWriter := io.Pipe()
+	responseReader, responseWriter := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(requestReader, responseWriter)
+	}()
+	c := client.NewClient(responseReader, requestWriter)
+
+	info, err := c.Info()
+	require.NoError(t, err)
+	assert.Equal(t, &host.Info{Version: "3.1.0", BatchMode: true}, info)
+
+	// notifications have no response so the next call gets the right response
+	require.NoError(t, c.Log("debug", "hello"))
+	require.NoError(t, c.Progress("working", 1, 3))
+
+	kc, err := c.KubeContext()
+	require.NoError(t, err)
+	assert.Equal(t, "mycluster", kc.Context)
+	assert.Equal(t, "jx", kc.Namespace)
+
+	value, err := c.PickValue("name", "cheese", true, "")
+	require.NoError(t, err)
+	assert.Equal(t, "cheese", value)
+
+	_, err = c.PickValue("name", "", true, "")
+	require.Error(t, err, "required prompts without a default should fail in batch mode")
+
+	confirmed, err := c.Confirm("continue", true, "")
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+
+	err = c.Call("host.unknown", nil, nil)
+	require.Error(t, err)
+	rpcErr, ok := err.(*host.Error)
+	require.True(t, ok, "should be a host.Error but was %T", err)
+	assert.Equal(t, host.ErrorCodeMethodNotFound, rpcErr.Code)
+
+	requestWriter.Close()
+	require.NoError(t, <-done)
+}
+
+func TestAttachPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the host protocol is not offered on windows")
+	}
+	goBinary, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("requires go to build the test plugin")
+	}
+	tmpDir, err := ioutil.TempDir("", "test-host-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	pluginPath := filepath.Join(tmpDir, "jx-host-test")
+	out, err := exec.Command(goBinary, "build", "-o", pluginPath, "./testdata/jx-host-test").CombinedOutput()
+	require.NoError(t, err, "failed to build test plugin: %s", string(out))
+
+	s := &host.Server{
+		BatchMode:   true,
+		KubeContext: fakeKubeContext,
+	}
+	stdout := &bytes.Buffer{}
+	cmd := exec.Command(pluginPath)
+	cmd.Stdout = stdout
+	stop, err := s.Attach(cmd)
+	require.NoError(t, err)
+	err = cmd.Run()
+	stop()
+	require.NoError(t, err)
+	assert.Equal(t, "batch=true context=mycluster namespace=jx name=cheese confirmed=true", strings.TrimSpace(stdout.String()))
+
+	// lets check the plugin falls back without the protocol
+	stdout.Reset()
+	cmd = exec.Command(pluginPath)
+	cmd.Stdout = stdout
+	require.NoError(t, cmd.Run())
+	assert.Equal(t, "host protocol not offered", strings.TrimSpace(stdout.String()))
+}
+
+func TestEnabled(t *testing.T) {
+	assert.False(t, host.Enabled(nil), "should be opt in")
+	assert.False(t, host.Enabled([]string{host.EnvEnabled + "=false"}))
+	assert.Equal(t, host.Supported(), host.Enabled([]string{host.EnvEnabled + "= TRUE"}))
+}
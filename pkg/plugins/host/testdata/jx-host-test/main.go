@@ -0,0 +1,53 @@
+// jx-host-test is a test plugin which uses the host protocol to resolve its kube context and prompt the user
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jenkins-x/jx/pkg/plugins/host/client"
+)
+
+func main() {
+	err := run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	c := client.New()
+	if c == nil {
+		fmt.Println("host protocol not offered")
+		return nil
+	}
+	defer c.Close()
+
+	info, err := c.Info()
+	if err != nil {
+		return err
+	}
+	kc, err := c.KubeContext()
+	if err != nil {
+		return err
+	}
+	err = c.Progress("resolving", 1, 2)
+	if err != nil {
+		return err
+	}
+	name, err := c.PickValue("name", "cheese", true, "the name to use")
+	if err != nil {
+		return err
+	}
+	confirmed, err := c.Confirm("continue", true, "")
+	if err != nil {
+		return err
+	}
+	err = c.Log("info", "finished "+name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("batch=%t context=%s namespace=%s name=%s confirmed=%t\n", info.BatchMode, kc.Context, kc.Namespace, name, confirmed)
+	return nil
+}
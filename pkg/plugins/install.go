@@ -0,0 +1,214 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins/oci"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OCICacheDir the directory inside the plugin bin dir where plugins pulled from OCI registries are cached by digest
+const OCICacheDir = "oci"
+
+// EnsurePluginInstalled ensures that the correct version of a plugin is installed locally
+// supporting binaries released on GitHub or published to OCI registries via `oci://` URLs.
+// The checksum of newly installed binaries is recorded so that `jx plugin doctor` can detect corruption
+func EnsurePluginInstalled(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+	answer, changed, err := installPlugin(plugin, pluginBinDir)
+	if err != nil || !changed || answer == "" {
+		return answer, err
+	}
+	err = WriteChecksum(answer)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to record checksum of plugin %s", plugin.Spec.Name)
+	}
+	return answer, nil
+}
+
+// installPlugin installs the plugin returning the path of the binary and whether it was changed
+func installPlugin(plugin jenkinsv1.Plugin, pluginBinDir string) (string, bool, error) {
+	name := plugin.Spec.Name
+	version := plugin.Spec.Version
+	path := filepath.Join(pluginBinDir, fmt.Sprintf("%s-%s", name, version))
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+
+	u, err := extensions.FindPluginUrl(plugin.Spec)
+	if err != nil || !oci.IsReference(u) {
+		answer, err := extensions.EnsurePluginInstalled(plugin, pluginBinDir)
+		return answer, !exists, err
+	}
+
+	ref, err := oci.ParseReference(u)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "invalid source for plugin %s", name)
+	}
+	// lets re-resolve mutable tags such as `latest` in case they have moved
+	if exists && !ref.IsMutable() {
+		return path, false, nil
+	}
+	if !exists {
+		log.Logger().Infof("Installing plugin %s version %s from %s into %s", termcolor.ColorInfo(name), termcolor.ColorInfo(version), termcolor.ColorInfo(u), pluginBinDir)
+	}
+
+	binaryName := name
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+	cached, err := oci.NewClient().Pull(ref, filepath.Join(pluginBinDir, OCICacheDir), binaryName)
+	if err != nil {
+		if exists {
+			log.Logger().Warnf("failed to check %s for a newer plugin %s so using the installed one: %s", u, name, err.Error())
+			return path, false, nil
+		}
+		return "", false, errors.Wrapf(err, "failed to pull plugin %s from %s", name, u)
+	}
+	if exists {
+		same, err := sameChecksum(cached, path)
+		if err != nil {
+			return "", false, err
+		}
+		if same {
+			return path, false, nil
+		}
+		log.Logger().Infof("Updating plugin %s version %s from %s", termcolor.ColorInfo(name), termcolor.ColorInfo(version), termcolor.ColorInfo(u))
+	}
+	err = files.CopyFile(cached, path)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to copy %s to %s", cached, path)
+	}
+	err = os.Chmod(path, 0755)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to make %s executable", path)
+	}
+	return path, true, nil
+}
+
+func sameChecksum(path1, path2 string) (bool, error) {
+	sum1, err := Checksum(path1)
+	if err != nil {
+		return false, err
+	}
+	sum2, err := Checksum(path2)
+	if err != nil {
+		return false, err
+	}
+	return sum1 == sum2, nil
+}
+
+// CreateOCIPlugin creates a plugin which is installed from the given `oci://` reference for all platforms
+func CreateOCIPlugin(name, version, source string) jenkinsv1.Plugin {
+	binaries := extensions.CreateBinaries(func(p extensions.Platform) string {
+		return source
+	})
+	binaryName := BinaryName(name)
+	shortName := strings.TrimPrefix(binaryName, "jx-")
+	return jenkinsv1.Plugin{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: shortName,
+		},
+		Spec: jenkinsv1.PluginSpec{
+			SubCommand:  shortName,
+			Binaries:    binaries,
+			Description: shortName + " binary",
+			Name:        binaryName,
+			Version:     version,
+		},
+	}
+}
+
+// ConfiguredPlugins returns the plugins declared in the jx configuration
+func ConfiguredPlugins(cfg *config.Config) ([]jenkinsv1.Plugin, error) {
+	var answer []jenkinsv1.Plugin
+	for _, p := range cfg.Plugins {
+		if !oci.IsReference(p.Source) {
+			return nil, errors.Errorf("unsupported source %s for plugin %s in jx config; only %s sources are supported", p.Source, p.Name, oci.Scheme)
+		}
+		ref, err := oci.ParseReference(p.Source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid source for plugin %s in jx config", p.Name)
+		}
+		version := p.Version
+		if version == "" {
+			version = ref.Tag
+			if ref.Digest != "" {
+				version = strings.TrimPrefix(ref.Digest, "sha256:")
+				if len(version) > 12 {
+					version = version[:12]
+				}
+			}
+		}
+		answer = append(answer, CreateOCIPlugin(p.Name, version, p.Source))
+	}
+	return answer, nil
+}
+
+// CatalogPlugins returns the default plugins along with any additional plugins declared in the jx configuration
+func CatalogPlugins(cfg *config.Config) ([]jenkinsv1.Plugin, error) {
+	configured, err := ConfiguredPlugins(cfg)
+	if err != nil {
+		return nil, err
+	}
+	answer := append([]jenkinsv1.Plugin{}, Plugins...)
+	return append(answer, configured...), nil
+}
+
+// VersionsDir the directory inside the plugin bin dir where specific versions of plugins are installed side by side
+const VersionsDir = "versions"
+
+// PluginVersion returns the plugin for the given version of a default or configured plugin
+func PluginVersion(plugin jenkinsv1.Plugin, version string) (jenkinsv1.Plugin, error) {
+	name := plugin.Spec.Name
+	shortName := strings.TrimPrefix(name, "jx-")
+	source := PluginSource(&plugin)
+	u, err := extensions.FindPluginUrl(plugin.Spec)
+	if err != nil {
+		return plugin, errors.Wrapf(err, "failed to find the URL of plugin %s", name)
+	}
+	if oci.IsReference(u) {
+		ref, err := oci.ParseReference(u)
+		if err != nil {
+			return plugin, errors.Wrapf(err, "invalid source for plugin %s", name)
+		}
+		return CreateOCIPlugin(name, version, oci.Scheme+ref.Name()+":"+version), nil
+	}
+	paths := strings.Split(source, "/")
+	if len(paths) != 3 || paths[0] != "github.com" {
+		return plugin, errors.Errorf("cannot choose the version of plugin %s from %s", name, source)
+	}
+	return extensions.CreateJXPlugin(paths[1], shortName, version), nil
+}
+
+// CreateCommunityPlugin creates the given version of a community plugin
+func CreateCommunityPlugin(name, version string) jenkinsv1.Plugin {
+	return extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, strings.TrimPrefix(name, "jx-"), version)
+}
+
+// PluginVersionDir returns the directory a specific version of a plugin is installed into so that it
+// does not replace the default version
+func PluginVersionDir(pluginBinDir, version string) string {
+	return filepath.Join(pluginBinDir, VersionsDir, version)
+}
+
+// EnsurePluginVersionInstalled ensures the plugin is installed side by side with any other versions
+func EnsurePluginVersionInstalled(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+	dir := PluginVersionDir(pluginBinDir, plugin.Spec.Version)
+	err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	return EnsurePluginInstalled(plugin, dir)
+}
@@ -0,0 +1,30 @@
+package plugins_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguredPlugins(t *testing.T) {
+	digest := strings.Repeat("0123456789abcdef", 4)
+	cfg := &config.Config{
+		Plugins: []config.PluginSource{
+			{Name: "foo", Source: "oci://ghcr.io/myorg/jx-foo:1.2.3"},
+			{Name: "bar", Source: "oci://ghcr.io/myorg/jx-bar@sha256:" + digest},
+		},
+	}
+	configured, err := plugins.ConfiguredPlugins(cfg)
+	require.NoError(t, err)
+	require.Len(t, configured, 2)
+	assert.Equal(t, "1.2.3", configured[0].Spec.Version)
+	assert.Equal(t, digest[:12], configured[1].Spec.Version)
+
+	cfg.Plugins = []config.PluginSource{{Name: "bar", Source: "oci://ghcr.io/myorg/jx-bar@sha256:abc123"}}
+	_, err = plugins.ConfiguredPlugins(cfg)
+	require.Error(t, err, "should reject a short digest")
+}
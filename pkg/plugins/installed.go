@@ -0,0 +1,80 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// CommunityPluginRefreshInterval how long an installed community plugin is used before checking for a newer release
+const CommunityPluginRefreshInterval = 24 * time.Hour
+
+// FindInstalledPlugin finds the latest version of the plugin of the given name such as `jx-foo` which has
+// previously been installed into the plugin bin dir. Returns an empty path if it is not installed
+func FindInstalledPlugin(pluginBinDir, name string) (string, string, error) {
+	fileInfos, err := ioutil.ReadDir(pluginBinDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", nil
+		}
+		return "", "", errors.Wrapf(err, "failed to read plugin dir %s", pluginBinDir)
+	}
+	prefix := name + "-"
+	path := ""
+	version := ""
+	var latest semver.Version
+	for _, f := range fileInfos {
+		fileName := f.Name()
+		if f.IsDir() || !strings.HasPrefix(fileName, prefix) {
+			continue
+		}
+		text := strings.TrimSuffix(strings.TrimPrefix(fileName, prefix), ".exe")
+		v, err := semver.ParseTolerant(text)
+		if err != nil {
+			// not a version so probably a different plugin such as `jx-foo-bar`
+			continue
+		}
+		if path == "" || v.GT(latest) {
+			latest = v
+			path = filepath.Join(pluginBinDir, fileName)
+			version = text
+		}
+	}
+	return path, version, nil
+}
+
+// InstalledPlugins returns the latest installed version of each plugin in the plugin bin dir indexed by plugin name such as `jx-foo`
+func InstalledPlugins(pluginBinDir string) (map[string]string, error) {
+	fileInfos, err := ioutil.ReadDir(pluginBinDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read plugin dir %s", pluginBinDir)
+	}
+	answer := map[string]string{}
+	latest := map[string]semver.Version{}
+	for _, f := range fileInfos {
+		fileName := strings.TrimSuffix(f.Name(), ".exe")
+		idx := strings.LastIndex(fileName, "-")
+		if f.IsDir() || !strings.HasPrefix(fileName, "jx-") || idx <= 0 {
+			continue
+		}
+		name := fileName[:idx]
+		text := fileName[idx+1:]
+		v, err := semver.ParseTolerant(text)
+		if err != nil {
+			continue
+		}
+		if old, ok := latest[name]; !ok || v.GT(old) {
+			latest[name] = v
+			answer[name] = text
+		}
+	}
+	return answer, nil
+}
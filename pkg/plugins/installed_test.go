@@ -0,0 +1,40 @@
+package plugins_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindInstalledPlugin(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-installed-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"jx-foo-0.1.0", "jx-foo-0.10.2", "jx-foo-0.9.0", "jx-foo-bar-1.0.0"} {
+		err = ioutil.WriteFile(filepath.Join(tmpDir, name), []byte("#!/bin/sh\n"), 0755)
+		require.NoError(t, err)
+	}
+
+	path, version, err := plugins.FindInstalledPlugin(tmpDir, "jx-foo")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "jx-foo-0.10.2"), path)
+	assert.Equal(t, "0.10.2", version)
+
+	path, _, err = plugins.FindInstalledPlugin(tmpDir, "jx-foo-bar")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "jx-foo-bar-1.0.0"), path)
+
+	path, _, err = plugins.FindInstalledPlugin(tmpDir, "jx-bar")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	path, _, err = plugins.FindInstalledPlugin(filepath.Join(tmpDir, "does-not-exist"), "jx-foo")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+}
@@ -0,0 +1,117 @@
+package plugins
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx/pkg/plugins/oci"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// DependencyMatrix the dependency matrix of the jx CLI in `dependency-matrix/matrix.yaml`
+type DependencyMatrix struct {
+	Dependencies []*Dependency `json:"dependencies"`
+}
+
+// Dependency a dependency in the matrix
+type Dependency struct {
+	Host       string `json:"host"`
+	Owner      string `json:"owner"`
+	Repo       string `json:"repo"`
+	URL        string `json:"url"`
+	Version    string `json:"version"`
+	VersionURL string `json:"versionURL"`
+}
+
+// DependencyMatrix returns the dependency matrix for the plugins and dependencies in the catalog
+func (c *Catalog) DependencyMatrix() *DependencyMatrix {
+	answer := &DependencyMatrix{}
+	add := func(e *CatalogEntry, plugin bool) {
+		if e.Source != "" {
+			answer.Dependencies = append(answer.Dependencies, ociDependency(e))
+			return
+		}
+		repo := e.Repository(plugin)
+		d := &Dependency{
+			Host:    "github.com",
+			Owner:   e.Org,
+			Repo:    repo,
+			URL:     fmt.Sprintf("https://github.com/%s/%s.git", e.Org, repo),
+			Version: e.Version,
+		}
+		if !e.Image {
+			d.VersionURL = fmt.Sprintf("https://github.com/%s/%s/releases/tag/v%s", e.Org, repo, e.Version)
+		}
+		answer.Dependencies = append(answer.Dependencies, d)
+	}
+	for i := range c.Plugins {
+		add(&c.Plugins[i], true)
+	}
+	for i := range c.Dependencies {
+		add(&c.Dependencies[i], false)
+	}
+	return answer
+}
+
+// ociDependency returns the dependency of a plugin published to an OCI registry
+func ociDependency(e *CatalogEntry) *Dependency {
+	d := &Dependency{
+		URL:     e.Source,
+		Version: e.Version,
+	}
+	ref, err := oci.ParseReference(e.Source)
+	if err == nil {
+		d.Host = ref.Registry
+		d.Owner = path.Dir(ref.Repository)
+		d.Repo = path.Base(ref.Repository)
+	}
+	return d
+}
+
+// YAML returns the matrix as YAML
+func (m *DependencyMatrix) YAML() ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// Markdown returns the matrix as a markdown table
+func (m *DependencyMatrix) Markdown() string {
+	buf := &strings.Builder{}
+	buf.WriteString("# Dependency Matrix\n\n")
+	buf.WriteString("Dependency | Sources | Version | Mismatched versions\n")
+	buf.WriteString("---------- | ------- | ------- | -------------------\n")
+	for _, d := range m.Dependencies {
+		fmt.Fprintf(buf, "[%s/%s](%s) |  | [%s](%s) | \n", d.Owner, d.Repo, d.URL, d.Version, d.VersionURL)
+	}
+	return buf.String()
+}
+
+const (
+	// MatrixYAMLFile the name of the dependency matrix YAML file
+	MatrixYAMLFile = "matrix.yaml"
+	// MatrixMarkdownFile the name of the dependency matrix markdown file
+	MatrixMarkdownFile = "matrix.md"
+)
+
+// WriteFiles writes the matrix YAML and markdown files to the directory
+func (m *DependencyMatrix) WriteFiles(dir string) error {
+	data, err := m.YAML()
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the dependency matrix")
+	}
+	path := filepath.Join(dir, MatrixYAMLFile)
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s", path)
+	}
+	path = filepath.Join(dir, MatrixMarkdownFile)
+	err = ioutil.WriteFile(path, []byte(m.Markdown()), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s", path)
+	}
+	return nil
+}
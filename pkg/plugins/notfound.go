@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// NotFoundCacheFileName the name of the file inside the jx home plugins dir which records plugins which do not exist
+	NotFoundCacheFileName = "not-found.yaml"
+
+	// NotFoundCacheTTL how long to remember that a community plugin does not exist
+	NotFoundCacheTTL = 24 * time.Hour
+)
+
+// NotFoundCache records the community plugins which were not found so we can avoid looking them up again
+type NotFoundCache struct {
+	// Plugins the plugin names and when they were not found
+	Plugins map[string]time.Time `json:"plugins,omitempty"`
+
+	path string
+}
+
+// NotFoundCacheFile returns the location of the not found cache file
+func NotFoundCacheFile() (string, error) {
+	dir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plugins", NotFoundCacheFileName), nil
+}
+
+// LoadNotFoundCache loads the not found cache from the default location
+func LoadNotFoundCache() (*NotFoundCache, error) {
+	path, err := NotFoundCacheFile()
+	if err != nil {
+		return nil, err
+	}
+	return LoadNotFoundCacheFile(path)
+}
+
+// LoadNotFoundCacheFile loads the not found cache from the given file returning an empty cache if the file does not exist
+func LoadNotFoundCacheFile(path string) (*NotFoundCache, error) {
+	c := &NotFoundCache{path: path}
+	err := yamls.LoadFile(path, c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load plugin not found cache %s", path)
+	}
+	return c, nil
+}
+
+// Save saves the cache to the file it was loaded from
+func (c *NotFoundCache) Save() error {
+	if c.path == "" {
+		return errors.Errorf("no file name for the plugin not found cache")
+	}
+	err := os.MkdirAll(filepath.Dir(c.path), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir for %s", c.path)
+	}
+	return yamls.SaveFile(c, c.path)
+}
+
+// IsNotFound returns true if the plugin was not found within the NotFoundCacheTTL of the given time
+func (c *NotFoundCache) IsNotFound(name string, now time.Time) bool {
+	t, ok := c.Plugins[name]
+	return ok && now.Sub(t) < NotFoundCacheTTL
+}
+
+// Add records that the plugin was not found at the given time removing any expired entries
+func (c *NotFoundCache) Add(name string, now time.Time) {
+	if c.Plugins == nil {
+		c.Plugins = map[string]time.Time{}
+	}
+	for k, t := range c.Plugins {
+		if now.Sub(t) >= NotFoundCacheTTL {
+			delete(c.Plugins, k)
+		}
+	}
+	c.Plugins[name] = now.UTC()
+}
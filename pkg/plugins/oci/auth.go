@@ -0,0 +1,95 @@
+package oci
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// dockerConfig the parts of the docker config file used for registry authentication
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Auth          string `json:"auth,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// Credentials the credentials for a registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// DockerConfigFile returns the location of the docker config file using $DOCKER_CONFIG if set
+func DockerConfigFile() string {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		dir = filepath.Join(homedir.HomeDir(), ".docker")
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+// LoadCredentials loads the credentials for the given registry from the docker config file.
+// Returns nil if there are no credentials for the registry
+func LoadCredentials(configFile, registry string) (*Credentials, error) {
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read docker config %s", configFile)
+	}
+	cfg := &dockerConfig{}
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse docker config %s", configFile)
+	}
+	for key, a := range cfg.Auths {
+		if registryHost(key) != registry {
+			continue
+		}
+		if a.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode auth for registry %s in %s", registry, configFile)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return nil, errors.Errorf("invalid auth for registry %s in %s", registry, configFile)
+			}
+			return &Credentials{Username: parts[0], Password: parts[1]}, nil
+		}
+		if a.IdentityToken != "" {
+			return &Credentials{Username: "<token>", Password: a.IdentityToken}, nil
+		}
+		if a.Username != "" {
+			return &Credentials{Username: a.Username, Password: a.Password}, nil
+		}
+	}
+	log.Logger().Debugf("no credentials for registry %s in %s", registry, configFile)
+	return nil, nil
+}
+
+// registryHost returns the host of a docker config auths key which may be a URL
+func registryHost(key string) string {
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	if idx := strings.Index(key, "/"); idx >= 0 {
+		key = key[:idx]
+	}
+	if key == "index.docker.io" {
+		return "docker.io"
+	}
+	return key
+}
@@ -0,0 +1,428 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/httphelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MediaTypeImageIndex the OCI image index media type
+	MediaTypeImageIndex = "application/vnd.oci.image.index.v1+json"
+	// MediaTypeImageManifest the OCI image manifest media type
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeDockerManifestList the docker manifest list media type
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// MediaTypeDockerManifest the docker manifest media type
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// AnnotationTitle the annotation used for the file name of a layer
+	AnnotationTitle = "org.opencontainers.image.title"
+)
+
+// Descriptor describes content in a registry
+type Descriptor struct {
+	MediaType   string            `json:"mediaType,omitempty"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *Platform         `json:"platform,omitempty"`
+}
+
+// Platform the platform of a manifest in an index
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// Manifest an image manifest or index
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Manifests     []Descriptor `json:"manifests,omitempty"`
+	Layers        []Descriptor `json:"layers,omitempty"`
+}
+
+// Client pulls plugin binaries from OCI registries
+type Client struct {
+	// HTTPClient the client used to talk to registries
+	HTTPClient *http.Client
+	// DockerConfigFile the docker config file used to find registry credentials
+	DockerConfigFile string
+	// PlainHTTP use http rather than https for all registries. Registries on localhost always use http
+	PlainHTTP bool
+	// OS the operating system to pull the binary for. Defaults to the current OS
+	OS string
+	// Arch the architecture to pull the binary for. Defaults to the current architecture
+	Arch string
+
+	token string
+}
+
+// NewClient creates a client with the default settings
+func NewClient() *Client {
+	return &Client{}
+}
+
+func (c *Client) defaults() {
+	if c.HTTPClient == nil {
+		c.HTTPClient = httphelpers.GetClient()
+	}
+	if c.DockerConfigFile == "" {
+		c.DockerConfigFile = DockerConfigFile()
+	}
+	if c.OS == "" {
+		c.OS = runtime.GOOS
+	}
+	if c.Arch == "" {
+		c.Arch = runtime.GOARCH
+	}
+}
+
+// Pull pulls the plugin binary for the current platform from the reference into the cache dir which is
+// keyed by the digest of the layer. Returns the path to the executable binary
+func (c *Client) Pull(ref *Reference, cacheDir, binaryName string) (string, error) {
+	c.defaults()
+
+	layer, err := c.findLayer(ref)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(cacheDir, strings.Replace(layer.Digest, ":", "-", 1))
+	path := filepath.Join(dir, binaryName)
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if exists {
+		log.Logger().Debugf("using cached plugin %s for %s", path, ref.String())
+		return path, nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "jx-oci-")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	downloadFile := filepath.Join(tmpDir, "blob")
+	err = c.downloadBlob(ref, layer, downloadFile)
+	if err != nil {
+		return "", err
+	}
+
+	title := layer.Annotations[AnnotationTitle]
+	extractDir := filepath.Join(tmpDir, "extract")
+	err = os.MkdirAll(extractDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", extractDir)
+	}
+	binaryFile := downloadFile
+	switch {
+	case strings.HasSuffix(title, ".tar.gz") || strings.HasSuffix(title, ".tgz") || strings.Contains(layer.MediaType, "tar+gzip"):
+		err = files.UnTargz(downloadFile, extractDir, []string{binaryName})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to extract %s", title)
+		}
+		binaryFile = filepath.Join(extractDir, binaryName)
+	case strings.HasSuffix(title, ".zip") || strings.HasSuffix(layer.MediaType, "zip"):
+		err = files.UnzipSpecificFiles(downloadFile, extractDir, binaryName)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to extract %s", title)
+		}
+		binaryFile = filepath.Join(extractDir, binaryName)
+	}
+	exists, err = files.FileExists(binaryFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", binaryFile)
+	}
+	if !exists {
+		return "", errors.Errorf("no binary %s in layer %s of %s", binaryName, layer.Digest, ref.String())
+	}
+
+	err = os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	err = files.CopyFile(binaryFile, path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to copy %s to %s", binaryFile, path)
+	}
+	err = os.Chmod(path, 0755)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to make %s executable", path)
+	}
+	return path, nil
+}
+
+// findLayer finds the layer containing the binary for the current platform
+func (c *Client) findLayer(ref *Reference) (*Descriptor, error) {
+	m, err := c.fetchManifest(ref, ref.Ref())
+	if err != nil {
+		return nil, err
+	}
+	platformSpecific := false
+	if len(m.Manifests) > 0 {
+		var found *Descriptor
+		for i := range m.Manifests {
+			d := &m.Manifests[i]
+			if d.Platform != nil && strings.EqualFold(d.Platform.OS, c.OS) && strings.EqualFold(d.Platform.Architecture, c.Arch) {
+				found = d
+				break
+			}
+		}
+		if found == nil {
+			return nil, errors.Errorf("no manifest for platform %s/%s in %s", c.OS, c.Arch, ref.String())
+		}
+		m, err = c.fetchManifest(ref, found.Digest)
+		if err != nil {
+			return nil, err
+		}
+		platformSpecific = true
+	}
+	if len(m.Layers) == 0 {
+		return nil, errors.Errorf("no layers in %s", ref.String())
+	}
+	if platformSpecific || len(m.Layers) == 1 {
+		return &m.Layers[0], nil
+	}
+
+	// lets find the layer for this platform from the file names
+	platformNames := []string{
+		strings.ToLower(c.OS + "-" + c.Arch),
+		strings.ToLower(c.OS + "_" + c.Arch),
+	}
+	for i := range m.Layers {
+		title := strings.ToLower(m.Layers[i].Annotations[AnnotationTitle])
+		for _, n := range platformNames {
+			if strings.Contains(title, n) {
+				return &m.Layers[i], nil
+			}
+		}
+	}
+	return nil, errors.Errorf("no layer for platform %s/%s in %s", c.OS, c.Arch, ref.String())
+}
+
+func (c *Client) fetchManifest(ref *Reference, reference string) (*Manifest, error) {
+	u := c.registryURL(ref, "manifests", reference)
+	accept := strings.Join([]string{MediaTypeImageIndex, MediaTypeImageManifest, MediaTypeDockerManifestList, MediaTypeDockerManifest}, ", ")
+	resp, err := c.get(ref, u, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read manifest from %s", u)
+	}
+	if strings.HasPrefix(reference, "sha256:") {
+		err = verifyDigest(data, reference)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid manifest from %s", u)
+		}
+	}
+	m := &Manifest{}
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse manifest from %s", u)
+	}
+	return m, nil
+}
+
+func (c *Client) downloadBlob(ref *Reference, layer *Descriptor, path string) error {
+	u := c.registryURL(ref, "blobs", layer.Digest)
+	resp, err := c.get(ref, u, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file %s", path)
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, h), resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", u)
+	}
+	actual := "sha256:" + hex.EncodeToString(h.Sum(nil))
+	if actual != layer.Digest {
+		return errors.Errorf("digest mismatch for %s: expected %s but got %s", u, layer.Digest, actual)
+	}
+	return nil
+}
+
+func verifyDigest(data []byte, digest string) error {
+	sum := sha256.Sum256(data)
+	actual := "sha256:" + hex.EncodeToString(sum[:])
+	if actual != digest {
+		return errors.Errorf("digest mismatch: expected %s but got %s", digest, actual)
+	}
+	return nil
+}
+
+func (c *Client) registryURL(ref *Reference, kind, reference string) string {
+	scheme := "https"
+	if c.PlainHTTP || isLocalhost(ref.Registry) {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, ref.Registry, ref.Repository, kind, reference)
+}
+
+func isLocalhost(registry string) bool {
+	host := registry
+	if idx := strings.LastIndex(host, ":"); idx >= 0 {
+		host = host[:idx]
+	}
+	return host == "localhost" || host == "127.0.0.1" || host == "[::1]"
+}
+
+// get performs a GET request handling the registry token authentication flow
+func (c *Client) get(ref *Reference, u, accept string) (*http.Response, error) {
+	resp, err := c.do(u, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		err = c.authenticate(ref, challenge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to authenticate with registry %s", ref.Registry)
+		}
+		resp, err = c.do(u, accept)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, errors.Errorf("failed to GET %s: status %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *Client) do(u, accept string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create http request for %s", u)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to GET %s", u)
+	}
+	return resp, nil
+}
+
+// authenticate handles a `WWW-Authenticate` challenge using the credentials from the docker config
+func (c *Client) authenticate(ref *Reference, challenge string) error {
+	creds, err := LoadCredentials(c.DockerConfigFile, ref.Registry)
+	if err != nil {
+		return err
+	}
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds == nil {
+			return errors.Errorf("no credentials for registry %s in %s", ref.Registry, c.DockerConfigFile)
+		}
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.SetBasicAuth(creds.Username, creds.Password)
+		c.token = req.Header.Get("Authorization")
+		return nil
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return errors.Errorf("missing realm in challenge %s", challenge)
+		}
+		tokenURL, err := url.Parse(realm)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse realm %s", realm)
+		}
+		q := tokenURL.Query()
+		if params["service"] != "" {
+			q.Set("service", params["service"])
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+		}
+		q.Set("scope", scope)
+		tokenURL.RawQuery = q.Encode()
+
+		req, err := http.NewRequest("GET", tokenURL.String(), nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create token request for %s", tokenURL.String())
+		}
+		if creds != nil {
+			req.SetBasicAuth(creds.Username, creds.Password)
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get token from %s", tokenURL.String())
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("failed to get token from %s: status %s", tokenURL.String(), resp.Status)
+		}
+		body := struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}{}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse token from %s", tokenURL.String())
+		}
+		token := body.Token
+		if token == "" {
+			token = body.AccessToken
+		}
+		if token == "" {
+			return errors.Errorf("no token returned from %s", tokenURL.String())
+		}
+		c.token = "Bearer " + token
+		return nil
+	default:
+		return errors.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+var challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseChallenge parses a challenge like `Bearer realm="https://auth",service="registry"`
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+	for _, m := range challengeParamRegex.FindAllStringSubmatch(parts[1], -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	return parts[0], params
+}
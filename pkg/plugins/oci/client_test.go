@@ -0,0 +1,247 @@
+package oci_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/plugins/oci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry a minimal OCI registry serving an index for linux/amd64 and darwin/arm64 with basic auth
+type fakeRegistry struct {
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	requests  []string
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	r := &fakeRegistry{
+		blobs:     map[string][]byte{},
+		manifests: map[string][]byte{},
+	}
+	var platforms []oci.Descriptor
+	for _, p := range []oci.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "darwin", Architecture: "arm64"}} {
+		name := fmt.Sprintf("jx-foo-%s-%s.tar.gz", p.OS, p.Architecture)
+		layer := r.addBlob(createTarGz(t, "jx-foo", "#!/bin/sh\necho "+p.OS+"\n"))
+		layer.MediaType = "application/vnd.oci.image.layer.v1.tar+gzip"
+		layer.Annotations = map[string]string{oci.AnnotationTitle: name}
+		manifest := r.addManifest(t, "", &oci.Manifest{
+			SchemaVersion: 2,
+			MediaType:     oci.MediaTypeImageManifest,
+			Layers:        []oci.Descriptor{layer},
+		})
+		manifest.Platform = &oci.Platform{OS: p.OS, Architecture: p.Architecture}
+		platforms = append(platforms, manifest)
+	}
+	r.addManifest(t, "1.2.3", &oci.Manifest{
+		SchemaVersion: 2,
+		MediaType:     oci.MediaTypeImageIndex,
+		Manifests:     platforms,
+	})
+	return r
+}
+
+func (r *fakeRegistry) addBlob(data []byte) oci.Descriptor {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	r.blobs[digest] = data
+	return oci.Descriptor{Digest: digest, Size: int64(len(data))}
+}
+
+func (r *fakeRegistry) addManifest(t *testing.T, tag string, m *oci.Manifest) oci.Descriptor {
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	d := r.addBlob(data)
+	d.MediaType = m.MediaType
+	r.manifests[d.Digest] = data
+	if tag != "" {
+		r.manifests[tag] = data
+	}
+	return d
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	user, password, ok := req.BasicAuth()
+	if !ok || user != "myuser" || password != "mypassword" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.requests = append(r.requests, req.URL.Path)
+	prefix := "/v2/myorg/jx-foo/"
+	path := strings.TrimPrefix(req.URL.Path, prefix)
+	switch {
+	case strings.HasPrefix(path, "manifests/"):
+		data := r.manifests[strings.TrimPrefix(path, "manifests/")]
+		if data == nil {
+			http.NotFound(w, req)
+			return
+		}
+		m := &oci.Manifest{}
+		_ = json.Unmarshal(data, m)
+		w.Header().Set("Content-Type", m.MediaType)
+		_, _ = w.Write(data)
+	case strings.HasPrefix(path, "blobs/"):
+		data := r.blobs[strings.TrimPrefix(path, "blobs/")]
+		if data == nil {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(data)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func createTarGz(t *testing.T, name, content string) []byte {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestPull(t *testing.T) {
+	registry := newFakeRegistry(t)
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tmpDir, err := ioutil.TempDir("", "test-oci-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	dockerConfig := filepath.Join(tmpDir, "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("myuser:mypassword"))
+	err = ioutil.WriteFile(dockerConfig, []byte(fmt.Sprintf(`{"auths": {"%s": {"auth": "%s"}}}`, host, auth)), 0600)
+	require.NoError(t, err)
+
+	client := &oci.Client{
+		DockerConfigFile: dockerConfig,
+		OS:               "darwin",
+		Arch:             "arm64",
+	}
+	ref, err := oci.ParseReference("oci://" + host + "/myorg/jx-foo:1.2.3")
+	require.NoError(t, err)
+
+	cacheDir := filepath.Join(tmpDir, "cache")
+	path, err := client.Pull(ref, cacheDir, "jx-foo")
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho darwin\n", string(data))
+	assert.True(t, strings.HasPrefix(path, filepath.Join(cacheDir, "sha256-")), "binary %s should be cached by digest", path)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, info.Mode()&0100 != 0, "binary %s should be executable", path)
+
+	// pulling again should reuse the cached binary without downloading the blob
+	registry.requests = nil
+	path2, err := client.Pull(ref, cacheDir, "jx-foo")
+	require.NoError(t, err)
+	assert.Equal(t, path, path2)
+	for _, r := range registry.requests {
+		assert.NotContains(t, r, "/blobs/", "should not download blobs for a cached binary")
+	}
+
+	// pull by digest
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(registry.manifests["1.2.3"]))
+	ref, err = oci.ParseReference("oci://" + host + "/myorg/jx-foo@" + digest)
+	require.NoError(t, err)
+	client.OS = "linux"
+	client.Arch = "amd64"
+	path, err = client.Pull(ref, cacheDir, "jx-foo")
+	require.NoError(t, err)
+	data, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho linux\n", string(data))
+
+	// unsupported platforms fail
+	client.OS = "windows"
+	_, err = client.Pull(ref, cacheDir, "jx-foo")
+	require.Error(t, err)
+}
+
+func TestPullWithoutCredentials(t *testing.T) {
+	server := httptest.NewServer(newFakeRegistry(t))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tmpDir, err := ioutil.TempDir("", "test-oci-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	client := &oci.Client{DockerConfigFile: filepath.Join(tmpDir, "does-not-exist.json")}
+	ref, err := oci.ParseReference("oci://" + host + "/myorg/jx-foo:1.2.3")
+	require.NoError(t, err)
+	_, err = client.Pull(ref, tmpDir, "jx-foo")
+	require.Error(t, err)
+}
+
+func TestParseReference(t *testing.T) {
+	testDigest := strings.Repeat("ab", 32)
+	testCases := []struct {
+		text     string
+		expected oci.Reference
+		name     string
+	}{
+		{
+			text:     "oci://ghcr.io/myorg/jx-foo:1.2.3",
+			expected: oci.Reference{Registry: "ghcr.io", Repository: "myorg/jx-foo", Tag: "1.2.3"},
+			name:     "ghcr.io/myorg/jx-foo",
+		},
+		{
+			text:     "oci://localhost:5000/jx-foo",
+			expected: oci.Reference{Registry: "localhost:5000", Repository: "jx-foo", Tag: "latest"},
+			name:     "localhost:5000/jx-foo",
+		},
+		{
+			text:     "oci://ghcr.io/myorg/jx-foo@sha256:" + testDigest,
+			expected: oci.Reference{Registry: "ghcr.io", Repository: "myorg/jx-foo", Digest: "sha256:" + testDigest},
+			name:     "ghcr.io/myorg/jx-foo",
+		},
+	}
+	for _, tc := range testCases {
+		ref, err := oci.ParseReference(tc.text)
+		require.NoError(t, err, "for %s", tc.text)
+		assert.Equal(t, tc.expected, *ref, "for %s", tc.text)
+		assert.Equal(t, tc.name, ref.Name(), "for %s", tc.text)
+	}
+
+	for _, text := range []string{"oci://ghcr.io", "https://ghcr.io/myorg/jx-foo", "oci://ghcr.io/myorg/jx-foo@md5:abc", "oci://ghcr.io/myorg/jx-foo@sha256:abc123", "oci://ghcr.io/myorg/jx-foo@sha256:" + strings.Repeat("z", 64)} {
+		_, err := oci.ParseReference(text)
+		assert.Error(t, err, "for %s", text)
+	}
+}
+
+func TestReferenceIsMutable(t *testing.T) {
+	for text, expected := range map[string]bool{
+		"oci://ghcr.io/myorg/jx-foo":                                   true,
+		"oci://ghcr.io/myorg/jx-foo:main":                              true,
+		"oci://ghcr.io/myorg/jx-foo:1.2.3":                             false,
+		"oci://ghcr.io/myorg/jx-foo:v1.2.3":                            false,
+		"oci://ghcr.io/myorg/jx-foo@sha256:" + strings.Repeat("0", 64): false,
+	} {
+		ref, err := oci.ParseReference(text)
+		require.NoError(t, err, "for %s", text)
+		assert.Equal(t, expected, ref.IsMutable(), "for %s", text)
+	}
+}
@@ -0,0 +1,107 @@
+package oci
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Scheme the URL scheme used for plugin sources in OCI registries
+	Scheme = "oci://"
+)
+
+// Reference a reference to an artifact in an OCI registry such as `oci://ghcr.io/myorg/jx-foo:1.2.3`
+type Reference struct {
+	// Registry the registry host such as `ghcr.io` or `localhost:5000`
+	Registry string
+	// Repository the repository inside the registry such as `myorg/jx-foo`
+	Repository string
+	// Tag the tag if the reference is not by digest
+	Tag string
+	// Digest the digest such as `sha256:abc...` if the reference is by digest
+	Digest string
+}
+
+// IsReference returns true if the text is an OCI reference
+func IsReference(text string) bool {
+	return strings.HasPrefix(text, Scheme)
+}
+
+// ParseReference parses a reference like `oci://registry/repo:tag` or `oci://registry/repo@sha256:...`
+func ParseReference(text string) (*Reference, error) {
+	if !IsReference(text) {
+		return nil, errors.Errorf("OCI reference %s does not start with %s", text, Scheme)
+	}
+	s := strings.TrimPrefix(text, Scheme)
+	idx := strings.Index(s, "/")
+	if idx <= 0 || idx == len(s)-1 {
+		return nil, errors.Errorf("OCI reference %s must be of the form %sregistry/repository:tag", text, Scheme)
+	}
+	r := &Reference{
+		Registry: s[:idx],
+	}
+	repo := s[idx+1:]
+	if at := strings.Index(repo, "@"); at >= 0 {
+		r.Digest = repo[at+1:]
+		repo = repo[:at]
+		if !strings.HasPrefix(r.Digest, "sha256:") {
+			return nil, errors.Errorf("unsupported digest %s in OCI reference %s", r.Digest, text)
+		}
+		if !isSHA256Hex(strings.TrimPrefix(r.Digest, "sha256:")) {
+			return nil, errors.Errorf("digest %s in OCI reference %s must be 64 hex characters", r.Digest, text)
+		}
+	} else if colon := strings.LastIndex(repo, ":"); colon >= 0 {
+		r.Tag = repo[colon+1:]
+		repo = repo[:colon]
+	}
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+	if repo == "" {
+		return nil, errors.Errorf("missing repository in OCI reference %s", text)
+	}
+	r.Repository = repo
+	return r, nil
+}
+
+func isSHA256Hex(text string) bool {
+	if len(text) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(text)
+	return err == nil
+}
+
+// IsMutable returns true if the reference is by a tag which may be moved such as `latest`.
+// References by digest or by a semantic version tag are treated as immutable
+func (r *Reference) IsMutable() bool {
+	if r.Digest != "" {
+		return false
+	}
+	_, err := semver.ParseTolerant(r.Tag)
+	return err != nil
+}
+
+// Ref returns the tag or digest to use when fetching the manifest
+func (r *Reference) Ref() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// Name returns the registry and repository without the tag or digest
+func (r *Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the reference as text
+func (r *Reference) String() string {
+	if r.Digest != "" {
+		return Scheme + r.Name() + "@" + r.Digest
+	}
+	return Scheme + r.Name() + ":" + r.Tag
+}
@@ -0,0 +1,29 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnvFileCannotAllow(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-policy-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	os.Setenv("JX3_HOME", tmpDir)
+	defer os.Unsetenv("JX3_HOME")
+	os.Setenv(EnvPolicyFile, filepath.Join("testdata", "env.yaml"))
+	defer os.Unsetenv(EnvPolicyFile)
+
+	policies, err := load(filepath.Join("testdata", "system.yaml"))
+	require.NoError(t, err, "failed to load policies")
+	assert.Equal(t, []string{filepath.Join("testdata", "system.yaml"), filepath.Join("testdata", "env.yaml")}, policies.Files, "policies.Files")
+
+	d := policies.Check(Plugin{Name: "jx-foo", Version: "1.0.0", Source: "github.com/someone/jx-foo"})
+	assert.False(t, d.Allowed, "the $%s file should not allow plugins denied by the system defaultAction", EnvPolicyFile)
+	assert.Equal(t, filepath.Join("testdata", "system.yaml"), d.File, "file")
+}
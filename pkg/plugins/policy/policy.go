@@ -0,0 +1,390 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvPolicyFile the environment variable for the location of a plugin policy file
+	EnvPolicyFile = "JX_PLUGIN_POLICY"
+
+	// PolicyFileName the name of the plugin policy file in the system and user directories
+	PolicyFileName = "plugin-policy.yaml"
+
+	// ActionAllow allows a plugin to be used
+	ActionAllow = "allow"
+
+	// ActionDeny denies a plugin from being used
+	ActionDeny = "deny"
+)
+
+// Rule a rule which allows or denies plugins
+type Rule struct {
+	// Name the name of the rule which is included in error messages
+	Name string `json:"name,omitempty"`
+	// Action whether matching plugins are allowed or denied
+	Action string `json:"action"`
+	// Plugins the plugin name globs such as `jx-gitops` or `jx-*`. Matches all plugins if empty
+	Plugins []string `json:"plugins,omitempty"`
+	// Sources the source globs such as `github.com/jenkins-x-plugins/*`. Matches all sources if empty
+	Sources []string `json:"sources,omitempty"`
+	// Versions the semver range such as `>=0.2.0 <1.0.0`. Matches all versions if empty
+	Versions string `json:"versions,omitempty"`
+
+	file         string
+	versionRange semver.Range
+}
+
+// Policy the plugin policy loaded from a file
+type Policy struct {
+	// DefaultAction the action if no rule matches; defaults to allow
+	DefaultAction string `json:"defaultAction,omitempty"`
+	// Rules the rules which are evaluated in order; the first matching rule wins
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Plugin the details of a plugin to check against the policies
+type Plugin struct {
+	// Name the binary name of the plugin such as `jx-gitops`
+	Name string
+	// Version the version of the plugin if known
+	Version string
+	// Source where the plugin comes from such as `github.com/jenkins-x-plugins/jx-gitops`
+	Source string
+}
+
+// Decision the result of checking a plugin against the policies
+type Decision struct {
+	// Allowed whether the plugin may be used
+	Allowed bool
+	// Rule the rule which matched or nil if the default action was used
+	Rule *Rule
+	// File the policy file which contained the matching rule or default action
+	File string
+}
+
+// Policies the combined policies from all of the policy files
+type Policies struct {
+	// Files the policy files which were loaded
+	Files []string
+	// DefaultAction the default action from the first organisation policy file which specifies one
+	DefaultAction string
+
+	defaultFile string
+	rules       []Rule
+	user        layer
+}
+
+// layer the rules from the user policy files which may only deny plugins
+type layer struct {
+	defaultAction string
+	defaultFile   string
+	rules         []Rule
+}
+
+// SystemPolicyFile returns the location of the system wide policy file
+func SystemPolicyFile() string {
+	if runtime.GOOS == "windows" {
+		dir := os.Getenv("ProgramData")
+		if dir == "" {
+			dir = `C:\ProgramData`
+		}
+		return filepath.Join(dir, "jx", PolicyFileName)
+	}
+	return filepath.Join("/etc", "jx", PolicyFileName)
+}
+
+// UserPolicyFile returns the location of the policy file in the jx home dir
+func UserPolicyFile() (string, error) {
+	dir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, PolicyFileName), nil
+}
+
+// Load loads the organisation policies from the system file followed by the user policies from the
+// $JX_PLUGIN_POLICY file and the user file.
+// The organisation policies are final; the user policies can only deny plugins which they allow
+func Load() (*Policies, error) {
+	return load(SystemPolicyFile())
+}
+
+func load(systemFile string) (*Policies, error) {
+	answer, err := LoadFiles(systemFile)
+	if err != nil {
+		return nil, err
+	}
+	var userFiles []string
+	if envFile := os.Getenv(EnvPolicyFile); envFile != "" {
+		userFiles = append(userFiles, envFile)
+	}
+	userFile, err := UserPolicyFile()
+	if err != nil {
+		return nil, err
+	}
+	userFiles = append(userFiles, userFile)
+	err = answer.LoadUserFiles(userFiles...)
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// LoadFiles loads the organisation policies from the given files which exist
+func LoadFiles(paths ...string) (*Policies, error) {
+	answer := &Policies{}
+	for _, p := range paths {
+		policy, err := loadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if policy == nil {
+			continue
+		}
+		err = answer.add(policy, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid plugin policy %s", p)
+		}
+	}
+	return answer, nil
+}
+
+// LoadUserFiles loads the user policies from the given files which exist.
+// User policies are only checked if the organisation policies allow a plugin so they can only add denies
+func (p *Policies) LoadUserFiles(paths ...string) error {
+	for _, f := range paths {
+		policy, err := loadFile(f)
+		if err != nil {
+			return err
+		}
+		if policy == nil {
+			continue
+		}
+		err = p.addUser(policy, f)
+		if err != nil {
+			return errors.Wrapf(err, "invalid plugin policy %s", f)
+		}
+	}
+	return nil
+}
+
+func loadFile(p string) (*Policy, error) {
+	exists, err := files.FileExists(p)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", p)
+	}
+	if !exists {
+		return nil, nil
+	}
+	policy := &Policy{}
+	err = yamls.LoadFile(p, policy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load plugin policy %s", p)
+	}
+	return policy, nil
+}
+
+func (p *Policies) add(policy *Policy, file string) error {
+	rules, err := parseRules(policy, file)
+	if err != nil {
+		return err
+	}
+	if policy.DefaultAction != "" && p.DefaultAction == "" {
+		p.DefaultAction = policy.DefaultAction
+		p.defaultFile = file
+	}
+	p.rules = append(p.rules, rules...)
+	p.Files = append(p.Files, file)
+	return nil
+}
+
+func (p *Policies) addUser(policy *Policy, file string) error {
+	rules, err := parseRules(policy, file)
+	if err != nil {
+		return err
+	}
+	if policy.DefaultAction != "" && p.user.defaultAction == "" {
+		p.user.defaultAction = policy.DefaultAction
+		p.user.defaultFile = file
+	}
+	p.user.rules = append(p.user.rules, rules...)
+	p.Files = append(p.Files, file)
+	return nil
+}
+
+func parseRules(policy *Policy, file string) ([]Rule, error) {
+	if policy.DefaultAction != "" {
+		err := validateAction(policy.DefaultAction)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid defaultAction")
+		}
+	}
+	var answer []Rule
+	for i := range policy.Rules {
+		r := policy.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("#%d", i+1)
+		}
+		err := validateAction(r.Action)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid rule %s", r.Name)
+		}
+		if r.Versions != "" {
+			r.versionRange, err = semver.ParseRange(r.Versions)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid versions %s in rule %s", r.Versions, r.Name)
+			}
+		}
+		for _, g := range append(append([]string{}, r.Plugins...), r.Sources...) {
+			if _, err := path.Match(g, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid glob %s in rule %s", g, r.Name)
+			}
+		}
+		r.file = file
+		answer = append(answer, r)
+	}
+	return answer, nil
+}
+
+func validateAction(action string) error {
+	if action != ActionAllow && action != ActionDeny {
+		return errors.Errorf("action %q must be %s or %s", action, ActionAllow, ActionDeny)
+	}
+	return nil
+}
+
+// Check checks the plugin against the rules returning the decision.
+// The organisation rules are evaluated first and a deny is final; if they allow the plugin the user rules
+// are evaluated which can deny it
+func (p *Policies) Check(plugin Plugin) Decision {
+	d := check(plugin, p.rules, p.DefaultAction, p.defaultFile)
+	if !d.Allowed {
+		return d
+	}
+	userDecision := check(plugin, p.user.rules, p.user.defaultAction, p.user.defaultFile)
+	if !userDecision.Allowed {
+		return userDecision
+	}
+	if d.Rule == nil && d.File == "" {
+		return userDecision
+	}
+	return d
+}
+
+func check(plugin Plugin, rules []Rule, defaultAction, defaultFile string) Decision {
+	for i := range rules {
+		r := &rules[i]
+		if r.Matches(plugin) {
+			return Decision{
+				Allowed: r.Action == ActionAllow,
+				Rule:    r,
+				File:    r.file,
+			}
+		}
+	}
+	return Decision{
+		Allowed: defaultAction != ActionDeny,
+		File:    defaultFile,
+	}
+}
+
+// Verify returns an error citing the rule if the plugin is denied
+func (p *Policies) Verify(plugin Plugin) error {
+	d := p.Check(plugin)
+	if d.Allowed {
+		return nil
+	}
+	return &DeniedError{Plugin: plugin, Decision: d}
+}
+
+// DeniedError the error returned when a plugin is denied by a policy
+type DeniedError struct {
+	Plugin   Plugin
+	Decision Decision
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("plugin %s is denied by %s", pluginText(e.Plugin), e.Decision.Reason())
+}
+
+// IsDenied returns true if the error was caused by a plugin being denied by a policy
+func IsDenied(err error) bool {
+	_, ok := errors.Cause(err).(*DeniedError)
+	return ok
+}
+
+// Reason returns a description of why the decision was made
+func (d *Decision) Reason() string {
+	if d.Rule != nil {
+		return fmt.Sprintf("rule %s in plugin policy %s", d.Rule.Name, d.File)
+	}
+	if d.File != "" {
+		return fmt.Sprintf("the defaultAction in plugin policy %s", d.File)
+	}
+	return "the default policy"
+}
+
+// Matches returns true if the rule matches the given plugin
+func (r *Rule) Matches(plugin Plugin) bool {
+	if !matchesAny(r.Plugins, plugin.Name) || !matchesAny(r.Sources, plugin.Source) {
+		return false
+	}
+	if r.Versions == "" {
+		return true
+	}
+	v, err := semver.ParseTolerant(plugin.Version)
+	if err != nil {
+		return false
+	}
+	return r.versionRange(v)
+}
+
+func matchesAny(globs []string, value string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, g := range globs {
+		if m, _ := path.Match(g, value); m {
+			return true
+		}
+	}
+	return false
+}
+
+func pluginText(plugin Plugin) string {
+	text := plugin.Name
+	if plugin.Version != "" {
+		text += " version " + plugin.Version
+	}
+	if plugin.Source != "" {
+		text += " from " + plugin.Source
+	}
+	return text
+}
+
+// String returns a description of the rule
+func (r *Rule) String() string {
+	var parts []string
+	if len(r.Plugins) > 0 {
+		parts = append(parts, "plugins: "+strings.Join(r.Plugins, ", "))
+	}
+	if len(r.Sources) > 0 {
+		parts = append(parts, "sources: "+strings.Join(r.Sources, ", "))
+	}
+	if r.Versions != "" {
+		parts = append(parts, "versions: "+r.Versions)
+	}
+	return fmt.Sprintf("%s %s (%s)", r.Action, r.Name, strings.Join(parts, "; "))
+}
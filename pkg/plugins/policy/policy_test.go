@@ -0,0 +1,89 @@
+package policy_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/plugins/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies(t *testing.T) {
+	t.Parallel()
+
+	policies, err := policy.LoadFiles(filepath.Join("testdata", "system.yaml"), filepath.Join("testdata", "does-not-exist.yaml"))
+	require.NoError(t, err, "failed to load policies")
+	assert.Len(t, policies.Files, 1, "policies.Files")
+
+	testCases := []struct {
+		plugin  policy.Plugin
+		allowed bool
+		rule    string
+	}{
+		{
+			plugin:  policy.Plugin{Name: "jx-gitops", Version: "0.1.9", Source: "github.com/jenkins-x-plugins/jx-gitops"},
+			allowed: false,
+			rule:    "deny-old-gitops",
+		},
+		{
+			plugin:  policy.Plugin{Name: "jx-gitops", Version: "0.3.3", Source: "github.com/jenkins-x-plugins/jx-gitops"},
+			allowed: true,
+			rule:    "jenkins-x-plugins",
+		},
+		{
+			plugin:  policy.Plugin{Name: "jx-foo", Version: "1.0.0", Source: "github.com/someone/jx-foo"},
+			allowed: false,
+		},
+		{
+			plugin:  policy.Plugin{Name: "jx-foo", Source: "file:///usr/local/bin/jx-foo"},
+			allowed: false,
+		},
+	}
+	for _, tc := range testCases {
+		d := policies.Check(tc.plugin)
+		assert.Equal(t, tc.allowed, d.Allowed, "allowed for %#v", tc.plugin)
+		if tc.rule != "" {
+			require.NotNil(t, d.Rule, "rule for %#v", tc.plugin)
+			assert.Equal(t, tc.rule, d.Rule.Name, "rule for %#v", tc.plugin)
+		} else {
+			assert.Nil(t, d.Rule, "rule for %#v", tc.plugin)
+		}
+	}
+
+	err = policies.Verify(policy.Plugin{Name: "jx-gitops", Version: "0.1.9"})
+	require.Error(t, err, "should deny old gitops")
+	assert.True(t, policy.IsDenied(err), "should be a denied error")
+	assert.Contains(t, err.Error(), "rule deny-old-gitops in plugin policy", "error message")
+}
+
+func TestPoliciesSystemTakesPrecedence(t *testing.T) {
+	t.Parallel()
+
+	policies, err := policy.LoadFiles(filepath.Join("testdata", "system.yaml"))
+	require.NoError(t, err, "failed to load policies")
+	err = policies.LoadUserFiles(filepath.Join("testdata", "user.yaml"))
+	require.NoError(t, err, "failed to load user policies")
+	assert.Equal(t, policy.ActionDeny, policies.DefaultAction, "policies.DefaultAction")
+	assert.Len(t, policies.Files, 2, "policies.Files")
+
+	d := policies.Check(policy.Plugin{Name: "jx-gitops", Version: "0.1.0"})
+	assert.False(t, d.Allowed, "user policy should not override the system policy")
+	require.NotNil(t, d.Rule, "rule")
+	assert.Equal(t, "deny-old-gitops", d.Rule.Name, "rule name")
+
+	d = policies.Check(policy.Plugin{Name: "jx-foo", Version: "1.0.0", Source: "github.com/someone/jx-foo"})
+	assert.False(t, d.Allowed, "user policy should not allow plugins denied by the system defaultAction")
+	assert.Nil(t, d.Rule, "rule")
+	assert.Equal(t, filepath.Join("testdata", "system.yaml"), d.File, "file")
+
+	d = policies.Check(policy.Plugin{Name: "jx-gitops", Version: "0.3.3", Source: "github.com/jenkins-x-plugins/jx-gitops"})
+	assert.True(t, d.Allowed, "system policy should allow jenkins-x-plugins")
+	require.NotNil(t, d.Rule, "rule")
+	assert.Equal(t, "jenkins-x-plugins", d.Rule.Name, "rule name")
+
+	d = policies.Check(policy.Plugin{Name: "jx-preview", Version: "0.1.0", Source: "github.com/jenkins-x-plugins/jx-preview"})
+	assert.False(t, d.Allowed, "user policy should be able to deny plugins the system policy allows")
+	require.NotNil(t, d.Rule, "rule")
+	assert.Equal(t, "no-previews", d.Rule.Name, "rule name")
+}
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/pkg/errors"
+)
+
+// ProjectPluginDir the directory inside a project which contains project local plugin executables and scripts
+var ProjectPluginDir = filepath.Join(".jx", "plugins")
+
+// FindProjectPluginDir walks up from the given directory looking for a `.jx/plugins` directory
+// returning an empty string if there is none
+func FindProjectPluginDir(dir string) (string, error) {
+	var err error
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find absolute path of %s", dir)
+	}
+	for {
+		path := filepath.Join(dir, ProjectPluginDir)
+		exists, err := files.DirExists(path)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to check if dir exists %s", path)
+		}
+		if exists {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// FindProjectPlugin finds the executable for the given command name such as `jx-release-notes` in the project plugin dir.
+// The executable may have a file extension such as `jx-release-notes.sh`
+func FindProjectPlugin(pluginDir, commandName string) (string, error) {
+	if pluginDir == "" {
+		return "", nil
+	}
+	paths, err := ProjectPlugins(pluginDir)
+	if err != nil {
+		return "", err
+	}
+	for _, path := range paths {
+		if ProjectPluginName(path) == commandName {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// ProjectPlugins returns the paths of the plugin executables in the project plugin dir
+func ProjectPlugins(pluginDir string) ([]string, error) {
+	fs, err := ioutil.ReadDir(pluginDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read project plugin dir %s", pluginDir)
+	}
+	var answer []string
+	for _, f := range fs {
+		name := f.Name()
+		if f.IsDir() || !strings.HasPrefix(name, "jx-") {
+			continue
+		}
+		if runtime.GOOS != "windows" && f.Mode()&0111 == 0 {
+			continue
+		}
+		answer = append(answer, filepath.Join(pluginDir, name))
+	}
+	return answer, nil
+}
+
+// ProjectPluginName returns the command name of a project plugin file by removing any file extension
+func ProjectPluginName(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,45 @@
+package plugins_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectPlugins(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "jx-project-plugins-")
+	require.NoError(t, err, "failed to create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	pluginDir := filepath.Join(tmpDir, plugins.ProjectPluginDir)
+	subDir := filepath.Join(tmpDir, "src", "cheese")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755), "failed to create plugin dir")
+	require.NoError(t, os.MkdirAll(subDir, 0755), "failed to create sub dir")
+
+	script := filepath.Join(pluginDir, "jx-release-notes.sh")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho notes\n"), 0755), "failed to write script")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(pluginDir, "README.md"), []byte("docs"), 0644), "failed to write readme")
+
+	dir, err := plugins.FindProjectPluginDir(subDir)
+	require.NoError(t, err, "failed to find project plugin dir")
+	assert.Equal(t, pluginDir, dir, "project plugin dir")
+
+	path, err := plugins.FindProjectPlugin(dir, "jx-release-notes")
+	require.NoError(t, err, "failed to find project plugin")
+	assert.Equal(t, script, path, "project plugin path")
+
+	path, err = plugins.FindProjectPlugin(dir, "jx-release")
+	require.NoError(t, err, "failed to find project plugin")
+	assert.Empty(t, path, "should not match a shorter command name")
+
+	paths, err := plugins.ProjectPlugins(dir)
+	require.NoError(t, err, "failed to list project plugins")
+	assert.Equal(t, []string{script}, paths, "project plugins")
+}
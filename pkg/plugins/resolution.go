@@ -0,0 +1,28 @@
+package plugins
+
+const (
+	// ResolutionLinked a local build linked via `jx plugin link`
+	ResolutionLinked = "linked"
+	// ResolutionDefault one of the default plugins of the CLI
+	ResolutionDefault = "default"
+	// ResolutionProject a project local plugin in a `.jx/plugins` dir
+	ResolutionProject = "project"
+	// ResolutionVersion a specific version of a plugin requested via `name@version` or `--plugin-version`
+	ResolutionVersion = "version"
+	// ResolutionLocal a plugin found by the plugin handler such as on the PATH or a community plugin
+	ResolutionLocal = "local"
+)
+
+// Resolution the plugin resolved for some command line arguments
+type Resolution struct {
+	// Name the plugin command name such as `jx-gitops`
+	Name string
+	// Path the path to the plugin executable
+	Path string
+	// Kind how the plugin was found such as ResolutionDefault
+	Kind string
+	// Version the version of the plugin if known
+	Version string
+	// Args the remaining arguments to pass to the plugin
+	Args []string
+}
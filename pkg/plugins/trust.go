@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TrustStoreFileName the name of the file inside the jx home plugins dir which records trusted community plugins
+	TrustStoreFileName = "trust.yaml"
+)
+
+// TrustedPlugin a community plugin which has been approved by the user
+type TrustedPlugin struct {
+	// Name the name of the plugin binary such as `jx-foo`
+	Name string `json:"name"`
+	// Repository the git repository the plugin is released from
+	Repository string `json:"repository"`
+	// Version the version of the plugin when it was approved
+	Version string `json:"version,omitempty"`
+	// URL the download URL of the plugin when it was approved
+	URL string `json:"url,omitempty"`
+	// ApprovedAt when the plugin was approved
+	ApprovedAt time.Time `json:"approvedAt"`
+}
+
+// TrustStore the community plugins the user has approved
+type TrustStore struct {
+	Plugins []TrustedPlugin `json:"plugins,omitempty"`
+
+	path string
+}
+
+// TrustStoreFile returns the location of the trust store file
+func TrustStoreFile() (string, error) {
+	dir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plugins", TrustStoreFileName), nil
+}
+
+// LoadTrustStore loads the trust store from the default location
+func LoadTrustStore() (*TrustStore, error) {
+	path, err := TrustStoreFile()
+	if err != nil {
+		return nil, err
+	}
+	return LoadTrustStoreFile(path)
+}
+
+// LoadTrustStoreFile loads the trust store from the given file returning an empty store if the file does not exist
+func LoadTrustStoreFile(path string) (*TrustStore, error) {
+	s := &TrustStore{path: path}
+	err := yamls.LoadFile(path, s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load plugin trust store %s", path)
+	}
+	return s, nil
+}
+
+// Save saves the trust store to the file it was loaded from
+func (s *TrustStore) Save() error {
+	if s.path == "" {
+		return errors.Errorf("no file name for the plugin trust store")
+	}
+	err := os.MkdirAll(filepath.Dir(s.path), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir for %s", s.path)
+	}
+	sort.Slice(s.Plugins, func(i, j int) bool {
+		return s.Plugins[i].Name < s.Plugins[j].Name
+	})
+	return yamls.SaveFile(s, s.path)
+}
+
+// Find returns the trusted plugin for the given name or nil if it is not trusted
+func (s *TrustStore) Find(name string) *TrustedPlugin {
+	for i := range s.Plugins {
+		if s.Plugins[i].Name == name {
+			return &s.Plugins[i]
+		}
+	}
+	return nil
+}
+
+// IsTrusted returns true if the plugin of the given name has been approved from the given repository.
+// The repository should be where the plugin is actually downloaded from so that a plugin of the same
+// name released from a different repository has to be approved again
+func (s *TrustStore) IsTrusted(name, repository string) bool {
+	p := s.Find(name)
+	return p != nil && p.Repository == repository
+}
+
+// Trust records that the given plugin has been approved
+func (s *TrustStore) Trust(p TrustedPlugin) {
+	if p.ApprovedAt.IsZero() {
+		p.ApprovedAt = time.Now().UTC()
+	}
+	existing := s.Find(p.Name)
+	if existing != nil {
+		*existing = p
+		return
+	}
+	s.Plugins = append(s.Plugins, p)
+}
+
+// Revoke removes the plugin of the given name returning true if it was trusted
+func (s *TrustStore) Revoke(name string) bool {
+	for i := range s.Plugins {
+		if s.Plugins[i].Name == name {
+			s.Plugins = append(s.Plugins[:i], s.Plugins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// BinaryName returns the binary name of a plugin such as `jx-foo` for the given name which may omit the `jx-` prefix
+func BinaryName(name string) string {
+	if strings.HasPrefix(name, "jx-") {
+		return name
+	}
+	return "jx-" + name
+}
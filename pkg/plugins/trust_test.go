@@ -0,0 +1,38 @@
+package plugins_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustStore(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "jx-trust-")
+	require.NoError(t, err, "failed to create temp dir")
+	path := filepath.Join(tmpDir, "plugins", plugins.TrustStoreFileName)
+
+	store, err := plugins.LoadTrustStoreFile(path)
+	require.NoError(t, err, "failed to load empty trust store")
+	assert.Empty(t, store.Plugins, "store.Plugins")
+
+	repo := "https://github.com/jenkins-x-plugins/jx-foo"
+	store.Trust(plugins.TrustedPlugin{Name: "jx-foo", Repository: repo, Version: "1.2.3"})
+	require.NoError(t, store.Save(), "failed to save trust store")
+
+	store, err = plugins.LoadTrustStoreFile(path)
+	require.NoError(t, err, "failed to reload trust store")
+	assert.True(t, store.IsTrusted("jx-foo", repo), "jx-foo should be trusted")
+	assert.False(t, store.IsTrusted("jx-foo", "https://github.com/somewhere-else/jx-foo"), "jx-foo from another repository should not be trusted")
+	assert.False(t, store.IsTrusted("jx-bar", repo), "jx-bar should not be trusted")
+	assert.False(t, store.Find("jx-foo").ApprovedAt.IsZero(), "ApprovedAt should be set")
+
+	assert.True(t, store.Revoke(plugins.BinaryName("foo")), "should revoke jx-foo")
+	assert.False(t, store.Revoke("jx-foo"), "should not revoke jx-foo twice")
+	assert.False(t, store.IsTrusted("jx-foo", repo), "jx-foo should no longer be trusted")
+}
@@ -2,68 +2,79 @@ package plugins
 
 import (
 	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
 )
 
 const (
+	// OctantVersion the default version of octant to use
+	OctantVersion = "0.21.0"
+)
+
+var (
+	// OctantJXVersion the default version of octant-jx plugin to use
+	OctantJXVersion = DefaultCatalog.DependencyVersion(OctantJXPluginName)
+
 	// AdminVersion the version of the jx admin plugin
-	AdminVersion = "0.0.189"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("admin").Version
+	AdminVersion = DefaultCatalog.pluginVersion("admin")
 
 	// ApplicationVersion the version of the jx application plugin
-	ApplicationVersion = "0.0.35"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("application").Version
+	ApplicationVersion = DefaultCatalog.pluginVersion("application")
 
 	// GitOpsVersion the version of the jx gitops plugin
-	GitOpsVersion = "0.3.3"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("gitops").Version
+	GitOpsVersion = DefaultCatalog.pluginVersion("gitops")
 
 	// HealthVersion the version of the jx health plugin
-	HealthVersion = "0.0.75"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("health").Version
+	HealthVersion = DefaultCatalog.pluginVersion("health")
 
 	// JenkinsVersion the version of the jx jenkins plugin
-	JenkinsVersion = "0.0.29"
-
-	// OctantVersion the default version of octant to use
-	OctantVersion = "0.21.0"
-
-	// OctantJXVersion the default version of octant-jx plugin to use
-	OctantJXVersion = "0.0.44"
+	//
+	// Deprecated: use DefaultCatalog.DependencyVersion("jx-jenkins")
+	JenkinsVersion = DefaultCatalog.DependencyVersion("jx-jenkins")
 
 	// PipelineVersion the version of the jx pipeline plugin
-	PipelineVersion = "0.0.150"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("pipeline").Version
+	PipelineVersion = DefaultCatalog.pluginVersion("pipeline")
 
 	// PreviewVersion the version of the jx preview plugin
-	PreviewVersion = "0.0.177"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("preview").Version
+	PreviewVersion = DefaultCatalog.pluginVersion("preview")
 
 	// ProjectVersion the version of the jx project plugin
-	ProjectVersion = "0.2.11"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("project").Version
+	ProjectVersion = DefaultCatalog.pluginVersion("project")
 
 	// PromoteVersion the version of the jx promote plugin
-	PromoteVersion = "0.0.274"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("promote").Version
+	PromoteVersion = DefaultCatalog.pluginVersion("promote")
 
 	// SecretVersion the version of the jx secret plugin
-	SecretVersion = "0.1.48"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("secret").Version
+	SecretVersion = DefaultCatalog.pluginVersion("secret")
 
 	// TestVersion the version of the jx test plugin
-	TestVersion = "0.0.48"
+	//
+	// Deprecated: use DefaultCatalog.Lookup("test").Version
+	TestVersion = DefaultCatalog.pluginVersion("test")
 
 	// VerifyVersion the version of the jx verify plugin
-	VerifyVersion = "0.1.4"
-)
+	//
+	// Deprecated: use DefaultCatalog.Lookup("verify").Version
+	VerifyVersion = DefaultCatalog.pluginVersion("verify")
 
-var (
 	// Plugins default plugins
-	Plugins = []jenkinsv1.Plugin{
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "admin", AdminVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "application", ApplicationVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "gitops", GitOpsVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "health", HealthVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "pipeline", PipelineVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "preview", PreviewVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "project", ProjectVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "promote", PromoteVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "secret", SecretVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "test", TestVersion),
-		extensions.CreateJXPlugin(jenkinsxPluginsOrganisation, "verify", VerifyVersion),
-	}
+	Plugins = DefaultCatalog.CreatePlugins()
 
 	// PluginMap a map of plugin names like `jx-gitops` to the Plugin object
 	PluginMap = map[string]*jenkinsv1.Plugin{}
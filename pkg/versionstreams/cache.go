@@ -0,0 +1,226 @@
+package versionstreams
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LockFileSuffix the suffix of the lock file next to each cached clone
+	LockFileSuffix = ".lock"
+
+	// DefaultLockTimeout how long to wait for another process to finish updating a cached clone
+	DefaultLockTimeout = 5 * time.Minute
+
+	lockPollInterval = 100 * time.Millisecond
+)
+
+var invalidKeyChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Cache a cache of shallow version stream clones indexed by git URL so that each upgrade only fetches the changes
+type Cache struct {
+	// Dir the cache directory. Defaults to `cache/versionstreams` in the jx home dir
+	Dir string
+	// GitClient the git client used to clone and fetch
+	GitClient gitclient.Interface
+	// LockTimeout how long to wait for the lock of a cached clone. Defaults to DefaultLockTimeout
+	LockTimeout time.Duration
+}
+
+// DefaultDir returns the default cache directory
+func DefaultDir() (string, error) {
+	dir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache", "versionstreams"), nil
+}
+
+// LocalDir returns the directory of a `file://` URL or a plain local directory and true
+// if the git URL refers to a checked out version stream on the local file system
+func LocalDir(gitURL string) (string, bool) {
+	dir := gitURL
+	if strings.HasPrefix(gitURL, "file://") {
+		u, err := url.Parse(gitURL)
+		if err != nil {
+			return "", false
+		}
+		dir = filepath.FromSlash(u.Path)
+	}
+	exists, err := files.DirExists(dir)
+	if err != nil || !exists {
+		return "", false
+	}
+	return dir, true
+}
+
+// Resolve returns the directory containing the version stream at the git URL and ref along with the function
+// which must be called once the caller has finished reading it.
+// Local directories are used as they are. Otherwise the cached clone is created or updated to the latest commit of
+// the ref using shallow and partial fetches and stays locked until the returned function is called so that
+// concurrent runs for other refs cannot change it while it is being read. An empty ref uses the default branch
+func (c *Cache) Resolve(gitURL, ref string) (string, func(), error) {
+	if dir, ok := LocalDir(gitURL); ok {
+		if ref != "" {
+			log.Logger().Warnf("ignoring the ref %s of the local version stream %s", ref, dir)
+		}
+		return dir, func() {}, nil
+	}
+
+	root := c.Dir
+	if root == "" {
+		var err error
+		root, err = DefaultDir()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	err := os.MkdirAll(root, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to create version stream cache dir %s", root)
+	}
+	if c.GitClient == nil {
+		c.GitClient = cli.NewCLIClient("", cmdrunner.QuietCommandRunner)
+	}
+
+	dir := filepath.Join(root, CacheKey(gitURL))
+	unlock, err := c.lock(dir + LockFileSuffix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = c.fetch(dir, gitURL, ref)
+	if err != nil {
+		// the cached clone may be corrupt so lets start again
+		log.Logger().Debugf("failed to update the cached clone of %s so cloning again: %s", gitURL, err.Error())
+		err = os.RemoveAll(dir)
+		if err != nil {
+			unlock()
+			return "", nil, errors.Wrapf(err, "failed to remove cached clone %s", dir)
+		}
+		err = c.fetch(dir, gitURL, ref)
+		if err != nil {
+			unlock()
+			return "", nil, errors.Wrapf(err, "failed to fetch version stream %s", gitURL)
+		}
+	}
+	return dir, unlock, nil
+}
+
+// fetch creates the clone if it does not exist and checks out the latest commit of the ref
+func (c *Cache) fetch(dir, gitURL, ref string) error {
+	exists, err := files.DirExists(filepath.Join(dir, ".git"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to check for the git dir in %s", dir)
+	}
+	if !exists {
+		log.Logger().Debugf("creating cached clone of %s in %s", gitURL, dir)
+		_, err = c.GitClient.Command(filepath.Dir(dir), "init", dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create git repository %s", dir)
+		}
+		_, err = c.GitClient.Command(dir, "remote", "add", "origin", gitURL)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add remote %s", gitURL)
+		}
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	_, err = c.GitClient.Command(dir, "fetch", "--depth", "1", "--filter=blob:none", "origin", ref)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch %s of %s", ref, gitURL)
+	}
+	_, err = c.GitClient.Command(dir, "reset", "--hard", "FETCH_HEAD")
+	if err != nil {
+		return errors.Wrapf(err, "failed to checkout %s of %s", ref, gitURL)
+	}
+	return nil
+}
+
+// lock creates the lock file containing the process ID waiting for any other process holding it returning the
+// function to release it. A lock file is removed as stale if the process which created it is no longer running
+// or, if the process ID cannot be read, the file is older than the timeout
+func (c *Cache) lock(path string) (func(), error) {
+	timeout := c.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, files.DefaultFileWritePermissions)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(os.Getpid()))
+			f.Close()
+			if err != nil {
+				_ = os.Remove(path)
+				return nil, errors.Wrapf(err, "failed to write lock file %s", path)
+			}
+			return func() {
+				_ = os.Remove(path)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrapf(err, "failed to create lock file %s", path)
+		}
+		if isStaleLock(path, timeout) {
+			log.Logger().Warnf("removing stale lock file %s", path)
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("timed out waiting for lock file %s. Remove it if no other jx process is running", path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// isStaleLock returns true if the process which created the lock file is no longer running
+func isStaleLock(path string, timeout time.Duration) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && pid > 0 {
+			return !processExists(pid)
+		}
+	}
+	// the process ID may not have been written yet
+	return time.Since(info.ModTime()) > timeout
+}
+
+// CacheKey returns the name of the cached clone of the git URL which is readable and unique.
+// Any user name and password in the URL are not included
+func CacheKey(gitURL string) string {
+	if u, err := url.Parse(gitURL); err == nil && u.User != nil {
+		u.User = nil
+		gitURL = u.String()
+	}
+	name := gitURL
+	if idx := strings.Index(name, "://"); idx >= 0 {
+		name = name[idx+3:]
+	}
+	name = strings.TrimSuffix(name, ".git")
+	name = strings.Trim(invalidKeyChars.ReplaceAllString(name, "-"), "-.")
+	sum := sha256.Sum256([]byte(gitURL))
+	return name + "-" + hex.EncodeToString(sum[:])[:8]
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package versionstreams
+
+import (
+	"os"
+	"syscall"
+)
+
+// processExists returns true if a process with the given ID is running
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
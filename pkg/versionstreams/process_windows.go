@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package versionstreams
+
+import (
+	"os"
+)
+
+// processExists returns true if a process with the given ID is running
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}